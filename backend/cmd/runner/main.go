@@ -0,0 +1,30 @@
+// Command runner serves the code sandbox to the backend over HTTP. It runs
+// in its own container, because isolating candidate code needs the
+// namespaces Docker's default seccomp and AppArmor profiles forbid, and
+// those should not be lifted for the container serving the API.
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/ai-interviewer/backend/internal/config"
+	"github.com/ai-interviewer/backend/internal/sandbox"
+)
+
+func main() {
+	cfg, err := config.LoadRunner()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	runner := sandbox.New(sandbox.Limits{Timeout: cfg.SandboxTimeout, MemoryMB: cfg.SandboxMemoryMB})
+
+	addr := fmt.Sprintf(":%s", cfg.Port)
+	log.Printf("Runner starting on %s", addr)
+
+	if err := http.ListenAndServe(addr, sandbox.NewServer(runner)); err != nil {
+		log.Fatalf("Runner failed to start: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"github.com/ai-interviewer/backend/internal/ai"
 	"github.com/ai-interviewer/backend/internal/config"
 	"github.com/ai-interviewer/backend/internal/database"
+	"github.com/ai-interviewer/backend/internal/dataset"
 	"github.com/ai-interviewer/backend/internal/handlers"
 	"github.com/ai-interviewer/backend/internal/repository"
 	"github.com/gorilla/mux"
@@ -40,15 +42,24 @@ func main() {
 
 	log.Println("Successfully connected to database")
 
-	// Initialize AI service
-	aiService, err := ai.NewAIService(cfg.GeminiAPIKey)
+	// Cap the memory of SQL question datasets
+	if err := dataset.LimitMemory(); err != nil {
+		log.Fatalf("Failed to limit SQLite memory: %v", err)
+	}
+
+	// Initialize AI provider
+	aiService, err := ai.NewProvider(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize AI service: %v", err)
 	}
+	log.Printf("Using AI provider: %s", cfg.AIProvider)
 
 	// Initialize repository and handlers
 	repo := repository.New(db.DB)
-	handler := handlers.New(repo, aiService)
+	handler := handlers.New(repo, aiService, cfg)
+
+	// Score answers that were saved while the AI provider was down
+	go handler.RunPendingEvaluations(context.Background(), cfg.PendingEvalPeriod)
 
 	// Setup router
 	router := mux.NewRouter()
@@ -80,6 +91,8 @@ func main() {
 	router.HandleFunc("/api/interview/{id}", handler.GetInterview).Methods("GET")
 	// --- FIX HERE: Add OPTIONS ---
 	router.HandleFunc("/api/interview/submit", handler.SubmitAnswer).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/interview/question/{id}/hint", handler.RequestHint).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/interview/question/{id}/model-answer", handler.GetModelAnswer).Methods("GET")
 	router.HandleFunc("/api/interviews", handler.GetUserInterviews).Methods("GET")
 
 	// Start server
@@ -1,11 +1,66 @@
+-- This script runs on every start. Tables are created with their current
+-- columns; the procedures below bring tables created by an older version
+-- up to date and skip any change that is already there.
+DELIMITER //
+
+DROP PROCEDURE IF EXISTS add_column //
+CREATE PROCEDURE add_column(IN p_table VARCHAR(64), IN p_column VARCHAR(64), IN p_definition TEXT)
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = DATABASE() AND table_name = p_table AND column_name = p_column
+    ) THEN
+        SET @ddl = CONCAT('ALTER TABLE `', p_table, '` ADD COLUMN `', p_column, '` ', p_definition);
+        PREPARE stmt FROM @ddl;
+        EXECUTE stmt;
+        DEALLOCATE PREPARE stmt;
+    END IF;
+END //
+
+DROP PROCEDURE IF EXISTS add_index //
+CREATE PROCEDURE add_index(IN p_table VARCHAR(64), IN p_index VARCHAR(64), IN p_columns TEXT)
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.statistics
+        WHERE table_schema = DATABASE() AND table_name = p_table AND index_name = p_index
+    ) THEN
+        SET @ddl = CONCAT('ALTER TABLE `', p_table, '` ADD INDEX `', p_index, '` (', p_columns, ')');
+        PREPARE stmt FROM @ddl;
+        EXECUTE stmt;
+        DEALLOCATE PREPARE stmt;
+    END IF;
+END //
+
+DROP PROCEDURE IF EXISTS add_foreign_key //
+CREATE PROCEDURE add_foreign_key(IN p_table VARCHAR(64), IN p_column VARCHAR(64), IN p_references TEXT)
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.key_column_usage
+        WHERE table_schema = DATABASE() AND table_name = p_table AND column_name = p_column
+            AND referenced_table_name IS NOT NULL
+    ) THEN
+        SET @ddl = CONCAT('ALTER TABLE `', p_table, '` ADD FOREIGN KEY (`', p_column, '`) ', p_references);
+        PREPARE stmt FROM @ddl;
+        EXECUTE stmt;
+        DEALLOCATE PREPARE stmt;
+    END IF;
+END //
+
+DELIMITER ;
+
 CREATE TABLE IF NOT EXISTS users (
     id INT AUTO_INCREMENT PRIMARY KEY,
     name VARCHAR(255) NOT NULL,
     email VARCHAR(255) NOT NULL UNIQUE,
+    profile JSON NULL, -- skills and projects extracted from the last resume
+    profile_updated_at TIMESTAMP NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     INDEX idx_email (email)
 );
 
+CALL add_column('users', 'profile', 'JSON NULL');
+CALL add_column('users', 'profile_updated_at', 'TIMESTAMP NULL');
+
 CREATE TABLE IF NOT EXISTS interviews (
     id INT AUTO_INCREMENT PRIMARY KEY,
     user_id INT NOT NULL,
@@ -13,6 +68,15 @@ CREATE TABLE IF NOT EXISTS interviews (
     difficulty ENUM('easy', 'medium', 'hard') NOT NULL,
     status ENUM('in_progress', 'completed') DEFAULT 'in_progress',
     score DECIMAL(5,2) NULL,
+    prompt_version VARCHAR(64) NULL,
+    max_follow_up_depth INT NOT NULL DEFAULT 0,
+    adaptive BOOLEAN NOT NULL DEFAULT FALSE,
+    mode ENUM('practice', 'assessment') NOT NULL DEFAULT 'practice',
+    feedback_summary TEXT NULL,
+    feedback_strengths JSON NULL,
+    feedback_improvements JSON NULL,
+    recommendation ENUM('hire', 'consider', 'no_hire') NULL,
+    feedback_prompt_version VARCHAR(64) NULL,
     started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     completed_at TIMESTAMP NULL,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
@@ -20,24 +84,203 @@ CREATE TABLE IF NOT EXISTS interviews (
     INDEX idx_status (status)
 );
 
+CALL add_column('interviews', 'prompt_version', 'VARCHAR(64) NULL');
+CALL add_column('interviews', 'feedback_summary', 'TEXT NULL');
+CALL add_column('interviews', 'feedback_strengths', 'JSON NULL');
+CALL add_column('interviews', 'feedback_improvements', 'JSON NULL');
+CALL add_column('interviews', 'recommendation', "ENUM('hire', 'consider', 'no_hire') NULL");
+CALL add_column('interviews', 'feedback_prompt_version', 'VARCHAR(64) NULL');
+CALL add_column('interviews', 'max_follow_up_depth', 'INT NOT NULL DEFAULT 0');
+CALL add_column('interviews', 'adaptive', 'BOOLEAN NOT NULL DEFAULT FALSE');
+CALL add_column('interviews', 'mode', "ENUM('practice', 'assessment') NOT NULL DEFAULT 'practice'");
+
 CREATE TABLE IF NOT EXISTS questions (
     id INT AUTO_INCREMENT PRIMARY KEY,
     interview_id INT NOT NULL,
     question_text TEXT NOT NULL,
-    question_type ENUM('technical', 'behavioral', 'coding') NOT NULL,
+    question_type ENUM('technical', 'behavioral', 'coding', 'system_design', 'sql', 'single_choice', 'multi_choice', 'short_answer') NOT NULL,
+    topics JSON NULL,
+    difficulty ENUM('easy', 'medium', 'hard') NULL,
+    target_difficulty ENUM('easy', 'medium', 'hard') NULL,
+    reference_answer TEXT NULL,
+    key_points JSON NULL,
+    options JSON NULL,
+    correct_options JSON NULL,
+    accepted_answers JSON NULL,
+    answer_pattern VARCHAR(512) NULL,
+    parent_question_id INT NULL,
+    depth INT NOT NULL DEFAULT 0,
     order_num INT NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (interview_id) REFERENCES interviews(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_question_id) REFERENCES questions(id) ON DELETE CASCADE,
     INDEX idx_interview_id (interview_id)
 );
 
+CALL add_column('questions', 'topics', 'JSON NULL');
+CALL add_column('questions', 'difficulty', "ENUM('easy', 'medium', 'hard') NULL");
+CALL add_column('questions', 'reference_answer', 'TEXT NULL');
+CALL add_column('questions', 'key_points', 'JSON NULL');
+CALL add_column('questions', 'parent_question_id', 'INT NULL');
+CALL add_column('questions', 'depth', 'INT NOT NULL DEFAULT 0');
+CALL add_foreign_key('questions', 'parent_question_id', 'REFERENCES questions(id) ON DELETE CASCADE');
+CALL add_column('questions', 'target_difficulty', "ENUM('easy', 'medium', 'hard') NULL");
+ALTER TABLE questions MODIFY COLUMN question_type ENUM('technical', 'behavioral', 'coding', 'system_design', 'sql', 'single_choice', 'multi_choice', 'short_answer') NOT NULL;
+CALL add_column('questions', 'options', 'JSON NULL');
+CALL add_column('questions', 'correct_options', 'JSON NULL');
+CALL add_column('questions', 'accepted_answers', 'JSON NULL');
+CALL add_column('questions', 'answer_pattern', 'VARCHAR(512) NULL');
+
+CREATE TABLE IF NOT EXISTS test_cases (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    question_id INT NOT NULL,
+    input TEXT NOT NULL,
+    expected_output TEXT NOT NULL,
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+    INDEX idx_test_case_question (question_id)
+);
+
+CREATE TABLE IF NOT EXISTS sql_datasets (
+    question_id INT PRIMARY KEY,
+    schema_sql TEXT NOT NULL,
+    seed_sql MEDIUMTEXT NOT NULL,
+    solution TEXT NOT NULL,
+    ordered BOOLEAN NOT NULL DEFAULT FALSE,
+    expected_columns JSON NOT NULL,
+    expected_rows JSON NOT NULL,
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE
+);
+
 CREATE TABLE IF NOT EXISTS responses (
     id INT AUTO_INCREMENT PRIMARY KEY,
     question_id INT NOT NULL,
     response_text TEXT NOT NULL,
+    language VARCHAR(32) NULL,
+    code TEXT NULL,
+    selected_options JSON NULL,
     feedback TEXT NULL,
     score DECIMAL(5,2) NULL,
+    status ENUM('scored', 'unscored', 'pending') NOT NULL DEFAULT 'scored',
+    strengths JSON NULL,
+    weaknesses JSON NULL,
+    key_points_covered JSON NULL,
+    key_points_missed JSON NULL,
+    score_spread DECIMAL(5,2) NULL,
+    sample_count INT NULL,
+    failed_samples INT NULL,
+    needs_review BOOLEAN NOT NULL DEFAULT FALSE,
+    review_reason VARCHAR(255) NULL,
+    hint_penalty DECIMAL(4,2) NOT NULL DEFAULT 0,
+    injection_flagged BOOLEAN NOT NULL DEFAULT FALSE,
+    prompt_version VARCHAR(64) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
+    INDEX idx_question_id (question_id),
+    INDEX idx_response_status (status)
+);
+
+CALL add_column('responses', 'status', "ENUM('scored', 'unscored') NOT NULL DEFAULT 'scored'");
+CALL add_column('responses', 'strengths', 'JSON NULL');
+CALL add_column('responses', 'weaknesses', 'JSON NULL');
+ALTER TABLE responses MODIFY COLUMN status ENUM('scored', 'unscored', 'pending') NOT NULL DEFAULT 'scored';
+CALL add_index('responses', 'idx_response_status', 'status');
+CALL add_column('responses', 'prompt_version', 'VARCHAR(64) NULL');
+CALL add_column('responses', 'key_points_covered', 'JSON NULL');
+CALL add_column('responses', 'key_points_missed', 'JSON NULL');
+CALL add_column('responses', 'score_spread', 'DECIMAL(5,2) NULL');
+CALL add_column('responses', 'sample_count', 'INT NULL');
+CALL add_column('responses', 'failed_samples', 'INT NULL');
+CALL add_column('responses', 'needs_review', 'BOOLEAN NOT NULL DEFAULT FALSE');
+CALL add_column('responses', 'review_reason', 'VARCHAR(255) NULL');
+CALL add_column('responses', 'hint_penalty', 'DECIMAL(4,2) NOT NULL DEFAULT 0');
+CALL add_column('responses', 'injection_flagged', 'BOOLEAN NOT NULL DEFAULT FALSE');
+CALL add_column('responses', 'language', 'VARCHAR(32) NULL');
+CALL add_column('responses', 'code', 'TEXT NULL');
+CALL add_column('responses', 'selected_options', 'JSON NULL');
+
+CREATE TABLE IF NOT EXISTS response_scores (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    response_id INT NOT NULL,
+    dimension ENUM('correctness', 'depth', 'communication', 'problem_solving', 'best_practices') NOT NULL,
+    score DECIMAL(4,2) NOT NULL,
+    justification TEXT NULL,
+    FOREIGN KEY (response_id) REFERENCES responses(id) ON DELETE CASCADE,
+    UNIQUE KEY uniq_response_dimension (response_id, dimension)
+);
+
+CREATE TABLE IF NOT EXISTS test_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    response_id INT NOT NULL,
+    test_case_id INT NOT NULL,
+    passed BOOLEAN NOT NULL,
+    output TEXT NULL,
+    error TEXT NULL,
+    duration_ms INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (response_id) REFERENCES responses(id) ON DELETE CASCADE,
+    FOREIGN KEY (test_case_id) REFERENCES test_cases(id) ON DELETE CASCADE,
+    UNIQUE KEY uniq_response_test_case (response_id, test_case_id)
+);
+
+CREATE TABLE IF NOT EXISTS code_diagnostics (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    response_id INT NOT NULL,
+    tool VARCHAR(32) NOT NULL,
+    severity ENUM('error', 'warning') NOT NULL,
+    line INT NULL,
+    col INT NULL,
+    message TEXT NOT NULL,
+    FOREIGN KEY (response_id) REFERENCES responses(id) ON DELETE CASCADE,
+    INDEX idx_diagnostic_response (response_id)
+);
+
+CREATE TABLE IF NOT EXISTS diagrams (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    response_id INT NOT NULL,
+    format ENUM('mermaid', 'plantuml') NOT NULL,
+    components JSON NULL,
+    edges JSON NULL,
+    error TEXT NULL,
+    FOREIGN KEY (response_id) REFERENCES responses(id) ON DELETE CASCADE,
+    INDEX idx_diagram_response (response_id)
+);
+
+CREATE TABLE IF NOT EXISTS query_results (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    response_id INT NOT NULL,
+    score DECIMAL(4,2) NOT NULL,
+    matched_rows INT NOT NULL,
+    expected_rows INT NOT NULL,
+    returned_rows INT NOT NULL,
+    result_columns JSON NULL,
+    result_rows JSON NULL,
+    reason VARCHAR(255) NULL,
+    error TEXT NULL,
+    FOREIGN KEY (response_id) REFERENCES responses(id) ON DELETE CASCADE,
+    UNIQUE KEY uniq_query_result_response (response_id)
+);
+
+CREATE TABLE IF NOT EXISTS hints (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    question_id INT NOT NULL,
+    level INT NOT NULL,
+    hint_text TEXT NOT NULL,
+    prompt_version VARCHAR(64) NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (question_id) REFERENCES questions(id) ON DELETE CASCADE,
-    INDEX idx_question_id (question_id)
+    UNIQUE KEY uniq_question_level (question_id, level)
 );
+
+CREATE TABLE IF NOT EXISTS model_answers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    response_id INT NOT NULL,
+    model_answer TEXT NOT NULL,
+    explanation TEXT NOT NULL,
+    prompt_version VARCHAR(64) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (response_id) REFERENCES responses(id) ON DELETE CASCADE,
+    UNIQUE KEY uniq_response (response_id)
+);
+
+DROP PROCEDURE IF EXISTS add_column;
+DROP PROCEDURE IF EXISTS add_index;
+DROP PROCEDURE IF EXISTS add_foreign_key;
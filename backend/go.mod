@@ -7,8 +7,11 @@ require (
 	github.com/google/generative-ai-go v0.15.0
 	github.com/gorilla/mux v1.8.1
 	github.com/joho/godotenv v1.5.1
+	github.com/ledongthuc/pdf v0.0.0-20220302134840-0c2507a12d80
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/rs/cors v1.10.1
 	google.golang.org/api v0.183.0
+	google.golang.org/grpc v1.64.0
 )
 
 require (
@@ -42,6 +45,5 @@ require (
 	golang.org/x/time v0.5.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20240604185151-ef581f913117 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240604185151-ef581f913117 // indirect
-	google.golang.org/grpc v1.64.0 // indirect
 	google.golang.org/protobuf v1.34.1 // indirect
 )
@@ -0,0 +1,145 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the provider while the circuit
+// breaker is open. It matches ErrUnavailable.
+var ErrCircuitOpen = &ProviderError{Kind: ErrorRetryable, Err: errors.New("AI provider circuit breaker is open")}
+
+// CircuitBreaker wraps a Provider and stops calling it after threshold
+// consecutive outage errors (unavailable or quota). After cooldown a single
+// trial call is let through; if it succeeds the circuit closes again.
+type CircuitBreaker struct {
+	next      Provider
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+func NewCircuitBreaker(next Provider, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		next:      next,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Open reports whether calls are currently being rejected.
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold && (b.now().Before(b.openUntil) || b.trial)
+}
+
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.now().Before(b.openUntil) || b.trial {
+		return false
+	}
+	// Half-open: let one call through to probe the provider
+	b.trial = true
+	return true
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if !errors.Is(err, ErrUnavailable) && !errors.Is(err, ErrQuotaExceeded) {
+		if b.failures >= b.threshold {
+			log.Printf("AI circuit breaker closed")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+		log.Printf("AI circuit breaker open for %s after %d consecutive failures: %v", b.cooldown, b.failures, err)
+	}
+}
+
+func (b *CircuitBreaker) GenerateQuestions(ctx context.Context, req QuestionRequest) ([]GeneratedQuestion, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+	questions, err := b.next.GenerateQuestions(ctx, req)
+	b.record(err)
+	return questions, err
+}
+
+func (b *CircuitBreaker) EvaluateAnswer(ctx context.Context, req EvaluationRequest) (*Evaluation, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+	eval, err := b.next.EvaluateAnswer(ctx, req)
+	b.record(err)
+	return eval, err
+}
+
+func (b *CircuitBreaker) GenerateFinalFeedback(ctx context.Context, req FinalFeedbackRequest) (*FinalFeedback, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+	feedback, err := b.next.GenerateFinalFeedback(ctx, req)
+	b.record(err)
+	return feedback, err
+}
+
+func (b *CircuitBreaker) GenerateFollowUp(ctx context.Context, req FollowUpRequest) (*GeneratedQuestion, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+	question, err := b.next.GenerateFollowUp(ctx, req)
+	b.record(err)
+	return question, err
+}
+
+func (b *CircuitBreaker) GenerateHint(ctx context.Context, req HintRequest) (*Hint, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+	hint, err := b.next.GenerateHint(ctx, req)
+	b.record(err)
+	return hint, err
+}
+
+func (b *CircuitBreaker) GenerateModelAnswer(ctx context.Context, req ModelAnswerRequest) (*ModelAnswer, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+	answer, err := b.next.GenerateModelAnswer(ctx, req)
+	b.record(err)
+	return answer, err
+}
+
+func (b *CircuitBreaker) ExtractProfile(ctx context.Context, req ProfileRequest) (*Profile, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+	profile, err := b.next.ExtractProfile(ctx, req)
+	b.record(err)
+	return profile, err
+}
@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// flakyProvider answers GenerateQuestions with err, counting the calls.
+type flakyProvider struct {
+	Provider
+	err   error
+	calls int
+}
+
+func (p *flakyProvider) GenerateQuestions(ctx context.Context, req QuestionRequest) ([]GeneratedQuestion, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	outage := statusError(http.StatusServiceUnavailable, "503")
+	quota := statusError(http.StatusTooManyRequests, "429")
+	invalid := statusError(http.StatusBadRequest, "400")
+
+	// Each step sets the provider's reply, moves the clock forward by
+	// advance and makes one call
+	type step struct {
+		reply    error
+		advance  time.Duration
+		wantErr  error // the error the call must match, nil for success
+		wantCall bool  // whether the provider was called
+		wantOpen bool  // Open() after the call
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after consecutive outages",
+			steps: []step{
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true},
+				{reply: quota, wantErr: ErrQuotaExceeded, wantCall: true, wantOpen: true},
+				{reply: nil, advance: 59 * time.Second, wantErr: ErrCircuitOpen, wantOpen: true},
+			},
+		},
+		{
+			name: "other errors and successes reset the count",
+			steps: []step{
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true},
+				{reply: invalid, wantErr: invalid, wantCall: true},
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true},
+				{reply: nil, wantCall: true},
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true},
+			},
+		},
+		{
+			name: "a successful trial closes it",
+			steps: []step{
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true},
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true, wantOpen: true},
+				{reply: nil, advance: time.Minute, wantCall: true},
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true},
+			},
+		},
+		{
+			name: "a failed trial reopens it for another cooldown",
+			steps: []step{
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true},
+				{reply: outage, wantErr: ErrUnavailable, wantCall: true, wantOpen: true},
+				{reply: outage, advance: time.Minute, wantErr: ErrUnavailable, wantCall: true, wantOpen: true},
+				{reply: nil, advance: 59 * time.Second, wantErr: ErrCircuitOpen, wantOpen: true},
+				{reply: nil, advance: time.Second, wantCall: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			provider := &flakyProvider{}
+			b := NewCircuitBreaker(provider, 2, time.Minute)
+			b.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				provider.err = s.reply
+				calls := provider.calls
+
+				_, err := b.GenerateQuestions(context.Background(), QuestionRequest{})
+				if s.wantErr == nil && err != nil || s.wantErr != nil && !errors.Is(err, s.wantErr) {
+					t.Errorf("step %d: err = %v, want %v", i+1, err, s.wantErr)
+				}
+				if called := provider.calls > calls; called != s.wantCall {
+					t.Errorf("step %d: provider called = %v, want %v", i+1, called, s.wantCall)
+				}
+				if open := b.Open(); open != s.wantOpen {
+					t.Errorf("step %d: Open() = %v, want %v", i+1, open, s.wantOpen)
+				}
+			}
+		})
+	}
+}
+
+// blockingProvider holds GenerateQuestions until release is closed.
+type blockingProvider struct {
+	Provider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) GenerateQuestions(ctx context.Context, req QuestionRequest) ([]GeneratedQuestion, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return nil, nil
+}
+
+func TestCircuitBreakerSingleTrial(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	flaky := &flakyProvider{err: statusError(http.StatusServiceUnavailable, "503")}
+	b := NewCircuitBreaker(flaky, 1, time.Minute)
+	b.now = func() time.Time { return now }
+	b.GenerateQuestions(context.Background(), QuestionRequest{})
+
+	// Half-open: the first call after the cooldown is the trial, and every
+	// other call is rejected until it returns
+	now = now.Add(time.Minute)
+	blocking := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+	b.next = blocking
+	done := make(chan error)
+	go func() {
+		_, err := b.GenerateQuestions(context.Background(), QuestionRequest{})
+		done <- err
+	}()
+	<-blocking.started
+
+	if !b.Open() {
+		t.Error("Open() = false during the trial call")
+	}
+	if _, err := b.GenerateQuestions(context.Background(), QuestionRequest{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second call during the trial: err = %v, want ErrCircuitOpen", err)
+	}
+
+	close(blocking.release)
+	if err := <-done; err != nil {
+		t.Fatalf("trial call: %v", err)
+	}
+	if b.Open() {
+		t.Error("Open() = true after a successful trial")
+	}
+}
@@ -0,0 +1,124 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CassetteMode selects whether a CassetteCompleter records or replays.
+type CassetteMode string
+
+const (
+	CassetteRecord CassetteMode = "record"
+	CassetteReplay CassetteMode = "replay"
+)
+
+// ErrCassetteMiss is returned in replay mode when no recording exists for
+// a prompt.
+var ErrCassetteMiss = errors.New("no cassette recording for prompt")
+
+// cassetteEntry is the on-disk form of one recorded interaction.
+type cassetteEntry struct {
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// CassetteCompleter records prompts and replies of the wrapped Completer to
+// dir, one JSON file per prompt keyed by the prompt's SHA-256 and, for
+// ensemble grading, the sample index, and can later
+// replay them without network access. This makes end-to-end runs and
+// grading bug reports reproducible.
+type CassetteCompleter struct {
+	next Completer
+	dir  string
+	mode CassetteMode
+	mu   sync.Mutex
+}
+
+// NewCassetteCompleter wraps next. In replay mode next is never called and
+// may be nil.
+func NewCassetteCompleter(next Completer, dir string, mode CassetteMode) (*CassetteCompleter, error) {
+	switch mode {
+	case CassetteRecord:
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+		}
+	case CassetteReplay:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode: %q", mode)
+	}
+
+	return &CassetteCompleter{next: next, dir: dir, mode: mode}, nil
+}
+
+// sampleKey is the context key of the sample index set by WithSample.
+type sampleKey struct{}
+
+// WithSample marks ctx as asking for the sample-th of several completions
+// of the same prompt, so that each is recorded and replayed separately.
+func WithSample(ctx context.Context, sample int) context.Context {
+	return context.WithValue(ctx, sampleKey{}, sample)
+}
+
+func (c *CassetteCompleter) Complete(ctx context.Context, prompt string) (string, error) {
+	sample, _ := ctx.Value(sampleKey{}).(int)
+	path := c.path(prompt, sample)
+
+	if c.mode == CassetteReplay {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w (%s)", ErrCassetteMiss, filepath.Base(path))
+		}
+		if err != nil {
+			return "", err
+		}
+
+		var entry cassetteEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return "", fmt.Errorf("invalid cassette %s: %w", filepath.Base(path), err)
+		}
+		return entry.Response, nil
+	}
+
+	response, err := c.next.Complete(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(cassetteEntry{Prompt: prompt, Response: response}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to record cassette: %w", err)
+	}
+
+	return response, nil
+}
+
+// CassetteKey returns the key under which the sample-th completion of
+// prompt is recorded. The first sample is keyed by the prompt's hash
+// alone. The random nonce of the candidate delimiter is left out of the
+// hash, so that a prompt replays whatever nonce it was rendered with.
+func CassetteKey(prompt string, sample int) string {
+	sum := sha256.Sum256([]byte(candidateNoncePattern.ReplaceAllString(prompt, candidateTag)))
+	key := hex.EncodeToString(sum[:])
+	if sample > 0 {
+		key += fmt.Sprintf("-%d", sample)
+	}
+	return key
+}
+
+func (c *CassetteCompleter) path(prompt string, sample int) string {
+	return filepath.Join(c.dir, CassetteKey(prompt, sample)+".json")
+}
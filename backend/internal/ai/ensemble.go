@@ -0,0 +1,180 @@
+package ai
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+)
+
+// Ensemble is a Provider that grades every answer several times, with one
+// or more member providers, and reports the median score and the spread
+// between samples. Everything else is delegated to the first member.
+type Ensemble struct {
+	members      []Provider
+	samples      int
+	reviewSpread float64
+}
+
+// NewEnsemble creates an Ensemble that samples each member samples times
+// per answer. Evaluations whose scores spread by more than reviewSpread
+// points are flagged for human review.
+func NewEnsemble(members []Provider, samples int, reviewSpread float64) *Ensemble {
+	if samples < 1 {
+		samples = 1
+	}
+	return &Ensemble{
+		members:      members,
+		samples:      samples,
+		reviewSpread: reviewSpread,
+	}
+}
+
+func (e *Ensemble) GenerateQuestions(ctx context.Context, req QuestionRequest) ([]GeneratedQuestion, error) {
+	return e.members[0].GenerateQuestions(ctx, req)
+}
+
+func (e *Ensemble) GenerateFinalFeedback(ctx context.Context, req FinalFeedbackRequest) (*FinalFeedback, error) {
+	return e.members[0].GenerateFinalFeedback(ctx, req)
+}
+
+func (e *Ensemble) GenerateFollowUp(ctx context.Context, req FollowUpRequest) (*GeneratedQuestion, error) {
+	return e.members[0].GenerateFollowUp(ctx, req)
+}
+
+func (e *Ensemble) GenerateHint(ctx context.Context, req HintRequest) (*Hint, error) {
+	return e.members[0].GenerateHint(ctx, req)
+}
+
+func (e *Ensemble) GenerateModelAnswer(ctx context.Context, req ModelAnswerRequest) (*ModelAnswer, error) {
+	return e.members[0].GenerateModelAnswer(ctx, req)
+}
+
+func (e *Ensemble) ExtractProfile(ctx context.Context, req ProfileRequest) (*Profile, error) {
+	return e.members[0].ExtractProfile(ctx, req)
+}
+
+// EvaluateAnswer samples all members concurrently. Score is the median of
+// the sample scores and feedback and the other fields come from the sample
+// closest to it. The rubric breaks the grade down with the median of each
+// dimension's sample scores. If every sample fails, the first outage error
+// (or else the first error) is returned.
+func (e *Ensemble) EvaluateAnswer(ctx context.Context, req EvaluationRequest) (*Evaluation, error) {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		evals []*Evaluation
+		errs  []error
+	)
+
+	for _, member := range e.members {
+		for i := 0; i < e.samples; i++ {
+			wg.Add(1)
+			go func(p Provider, sample int) {
+				defer wg.Done()
+				eval, err := p.EvaluateAnswer(WithSample(ctx, sample), req)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+				evals = append(evals, eval)
+			}(member, i)
+		}
+	}
+	wg.Wait()
+
+	if len(evals) == 0 {
+		for _, err := range errs {
+			if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrQuotaExceeded) {
+				return nil, err
+			}
+		}
+		return nil, errs[0]
+	}
+
+	// Samples finish in any order; sorting them makes ties go to the lower
+	// score every time
+	slices.SortStableFunc(evals, func(a, b *Evaluation) int { return cmp.Compare(a.Score, b.Score) })
+	scores := make([]float64, 0, len(evals))
+	for _, ev := range evals {
+		scores = append(scores, ev.Score)
+	}
+	score := median(scores)
+	spread := slices.Max(scores) - slices.Min(scores)
+
+	closest := evals[0]
+	for _, ev := range evals {
+		if math.Abs(ev.Score-score) < math.Abs(closest.Score-score) {
+			closest = ev
+		}
+	}
+
+	result := *closest
+	for _, ev := range evals {
+		// One grader noticing an injection attempt is enough
+		result.InjectionDetected = result.InjectionDetected || ev.InjectionDetected
+	}
+	if rubric := medianRubric(evals); len(rubric) > 0 {
+		result.Rubric = rubric
+	}
+	result.Score = score
+	result.ScoreSpread = math.Round(spread*100) / 100
+	result.Samples = len(evals)
+	result.FailedSamples = len(errs)
+	if spread > e.reviewSpread {
+		result.NeedsReview = true
+		result.ReviewReason = fmt.Sprintf("graders disagreed by %.1f points across %d samples", spread, len(evals))
+	}
+
+	return &result, nil
+}
+
+// medianRubric scores each rubric dimension with the median of its sample
+// scores, keeping the justification of the sample closest to the median.
+func medianRubric(evals []*Evaluation) []RubricScore {
+	byDimension := make(map[string][]RubricScore)
+	for _, ev := range evals {
+		for _, r := range ev.Rubric {
+			byDimension[r.Dimension] = append(byDimension[r.Dimension], r)
+		}
+	}
+
+	var rubric []RubricScore
+	for _, dim := range RubricDimensions {
+		samples := byDimension[dim]
+		if len(samples) == 0 {
+			continue
+		}
+		values := make([]float64, 0, len(samples))
+		for _, r := range samples {
+			values = append(values, r.Score)
+		}
+		m := median(values)
+
+		closest := samples[0]
+		for _, r := range samples {
+			if math.Abs(r.Score-m) < math.Abs(closest.Score-m) {
+				closest = r
+			}
+		}
+		rubric = append(rubric, RubricScore{Dimension: dim, Score: m, Justification: closest.Justification})
+	}
+	return rubric
+}
+
+// median returns the median of values.
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return math.Round((sorted[n/2-1]+sorted[n/2])/2*100) / 100
+}
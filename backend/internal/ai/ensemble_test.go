@@ -0,0 +1,126 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// sampledProvider grades the nth sample of an answer with evals[n], or
+// fails it when evals[n] is nil.
+type sampledProvider struct {
+	Provider
+	evals []*Evaluation
+}
+
+func (p *sampledProvider) EvaluateAnswer(ctx context.Context, req EvaluationRequest) (*Evaluation, error) {
+	sample, _ := ctx.Value(sampleKey{}).(int)
+	if p.evals[sample] == nil {
+		return nil, statusError(http.StatusServiceUnavailable, "503")
+	}
+	eval := *p.evals[sample]
+	return &eval, nil
+}
+
+// graded returns an evaluation scored score, with rubric scores dims.
+func graded(score float64, feedback string, dims ...float64) *Evaluation {
+	eval := &Evaluation{Score: score, Feedback: feedback}
+	for i, s := range dims {
+		eval.Rubric = append(eval.Rubric, RubricScore{Dimension: RubricDimensions[i], Score: s, Justification: feedback})
+	}
+	return eval
+}
+
+func TestEnsembleEvaluateAnswer(t *testing.T) {
+	tests := []struct {
+		name         string
+		evals        []*Evaluation
+		wantScore    float64
+		wantFeedback string
+		wantRubric   []float64
+		wantSpread   float64
+		wantSamples  int
+		wantFailed   int
+		wantReview   bool
+	}{
+		{
+			name:         "one sample",
+			evals:        []*Evaluation{graded(6, "a", 6, 6)},
+			wantScore:    6,
+			wantFeedback: "a",
+			wantRubric:   []float64{6, 6},
+			wantSamples:  1,
+		},
+		{
+			name:         "median of an odd number of samples",
+			evals:        []*Evaluation{graded(9, "high", 10, 8), graded(4, "low", 2, 6), graded(6, "mid", 7, 5)},
+			wantScore:    6,
+			wantFeedback: "mid",
+			wantRubric:   []float64{7, 6},
+			wantSpread:   5,
+			wantSamples:  3,
+			wantReview:   true,
+		},
+		{
+			name:         "median of an even number of samples",
+			evals:        []*Evaluation{graded(7, "a", 7, 7), graded(8, "b", 9, 7)},
+			wantScore:    7.5,
+			wantFeedback: "a",
+			wantRubric:   []float64{8, 7},
+			wantSpread:   1,
+			wantSamples:  2,
+		},
+		{
+			name:         "failed samples are left out",
+			evals:        []*Evaluation{graded(5, "a", 5), nil, graded(6, "b", 6)},
+			wantScore:    5.5,
+			wantFeedback: "a",
+			wantRubric:   []float64{5.5},
+			wantSpread:   1,
+			wantSamples:  2,
+			wantFailed:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEnsemble([]Provider{&sampledProvider{evals: tt.evals}}, len(tt.evals), 2)
+			eval, err := e.EvaluateAnswer(context.Background(), EvaluationRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if eval.Score != tt.wantScore || eval.Feedback != tt.wantFeedback {
+				t.Errorf("Score, Feedback = %v, %q, want %v, %q", eval.Score, eval.Feedback, tt.wantScore, tt.wantFeedback)
+			}
+			var rubric []float64
+			for _, r := range eval.Rubric {
+				rubric = append(rubric, r.Score)
+			}
+			if len(rubric) != len(tt.wantRubric) {
+				t.Fatalf("rubric = %v, want %v", rubric, tt.wantRubric)
+			}
+			for i := range rubric {
+				if rubric[i] != tt.wantRubric[i] {
+					t.Errorf("rubric = %v, want %v", rubric, tt.wantRubric)
+					break
+				}
+			}
+			if eval.ScoreSpread != tt.wantSpread || eval.Samples != tt.wantSamples || eval.FailedSamples != tt.wantFailed {
+				t.Errorf("ScoreSpread, Samples, FailedSamples = %v, %d, %d, want %v, %d, %d",
+					eval.ScoreSpread, eval.Samples, eval.FailedSamples, tt.wantSpread, tt.wantSamples, tt.wantFailed)
+			}
+			if eval.NeedsReview != tt.wantReview {
+				t.Errorf("NeedsReview = %v, want %v", eval.NeedsReview, tt.wantReview)
+			}
+		})
+	}
+}
+
+func TestEnsembleAllSamplesFail(t *testing.T) {
+	e := NewEnsemble([]Provider{&sampledProvider{evals: []*Evaluation{nil, nil}}}, 2, 2)
+	if _, err := e.EvaluateAnswer(context.Background(), EvaluationRequest{}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want one matching ErrUnavailable", err)
+	}
+}
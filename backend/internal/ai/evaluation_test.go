@@ -0,0 +1,216 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// evaluationJSON returns a valid reply to evaluationSchema for the key
+// points "Indexes" and "Caching", after applying edit to it.
+func evaluationJSON(t *testing.T, edit func(reply map[string]any)) string {
+	t.Helper()
+	rubric := map[string]any{}
+	for i, dim := range RubricDimensions {
+		rubric[dim] = map[string]any{"score": float64(5 + i), "justification": "Because."}
+	}
+	reply := map[string]any{
+		"rubric":             rubric,
+		"feedback":           " Solid answer. ",
+		"strengths":          []string{"clear"},
+		"weaknesses":         []string{},
+		"key_points_covered": []string{"indexes"},
+		"key_points_missed":  []string{"  Caching "},
+		"injection_detected": false,
+	}
+	if edit != nil {
+		edit(reply)
+	}
+	b, err := json.Marshal(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestParseEvaluation(t *testing.T) {
+	keyPoints := []string{"Indexes", "Caching"}
+	dimension := func(dim string, entry any) func(map[string]any) {
+		return func(reply map[string]any) {
+			rubric := reply["rubric"].(map[string]any)
+			if entry == nil {
+				delete(rubric, dim)
+				return
+			}
+			rubric[dim] = entry
+		}
+	}
+
+	tests := []struct {
+		name    string
+		reply   func(t *testing.T) string
+		wantErr string
+	}{
+		{
+			name:  "valid",
+			reply: func(t *testing.T) string { return evaluationJSON(t, nil) },
+		},
+		{
+			name:  "code fence",
+			reply: func(t *testing.T) string { return "```json\n" + evaluationJSON(t, nil) + "\n```" },
+		},
+		{
+			name:    "prose around the JSON",
+			reply:   func(t *testing.T) string { return "Here you go: " + evaluationJSON(t, nil) },
+			wantErr: "does not match schema",
+		},
+		{
+			name:    "trailing data",
+			reply:   func(t *testing.T) string { return evaluationJSON(t, nil) + " {}" },
+			wantErr: "unexpected data",
+		},
+		{
+			name: "unknown field",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) { r["score"] = 10 })
+			},
+			wantErr: "does not match schema",
+		},
+		{
+			name: "missing feedback",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) { delete(r, "feedback") })
+			},
+			wantErr: "feedback",
+		},
+		{
+			name: "blank feedback",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) { r["feedback"] = "  " })
+			},
+			wantErr: "feedback",
+		},
+		{
+			name: "missing weaknesses",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) { delete(r, "weaknesses") })
+			},
+			wantErr: "strengths and weaknesses",
+		},
+		{
+			name: "missing injection flag",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) { delete(r, "injection_detected") })
+			},
+			wantErr: "injection_detected",
+		},
+		{
+			name: "missing dimension",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, dimension("depth", nil))
+			},
+			wantErr: "rubric must score exactly",
+		},
+		{
+			name: "duplicate dimension",
+			reply: func(t *testing.T) string {
+				return strings.Replace(evaluationJSON(t, nil), `"rubric":{`, `"rubric":{"depth":{"score":10,"justification":"Again."},`, 1)
+			},
+			wantErr: `rubric scores "depth" more than once`,
+		},
+		{
+			name: "unknown field in a dimension",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, dimension("depth", map[string]any{"score": 5, "justification": "Because.", "weight": 2}))
+			},
+			wantErr: "does not match schema",
+		},
+		{
+			name: "unknown dimension",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) {
+					dimension("depth", nil)(r)
+					dimension("style", map[string]any{"score": 5, "justification": "Because."})(r)
+				})
+			},
+			wantErr: `missing a score for "depth"`,
+		},
+		{
+			name: "score out of range",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, dimension("depth", map[string]any{"score": 11, "justification": "Because."}))
+			},
+			wantErr: "outside 0-10",
+		},
+		{
+			name: "negative score",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, dimension("depth", map[string]any{"score": -1, "justification": "Because."}))
+			},
+			wantErr: "outside 0-10",
+		},
+		{
+			name: "missing justification",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, dimension("depth", map[string]any{"score": 5}))
+			},
+			wantErr: "no justification",
+		},
+		{
+			name: "unlisted key point",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) { r["key_points_covered"] = []string{"Indexes", "Sharding"} })
+			},
+			wantErr: "not one of the listed key points",
+		},
+		{
+			name: "key point listed twice",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) { r["key_points_covered"] = []string{"Indexes", "Caching"} })
+			},
+			wantErr: "more than once",
+		},
+		{
+			name: "key point not listed",
+			reply: func(t *testing.T) string {
+				return evaluationJSON(t, func(r map[string]any) { r["key_points_missed"] = []string{} })
+			},
+			wantErr: "every key point",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval, err := parseEvaluation(tt.reply(t), keyPoints)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The score is the mean of the rubric scores 5 to 9, whatever
+			// the model claims
+			if eval.Score != 7 {
+				t.Errorf("Score = %v, want 7", eval.Score)
+			}
+			if len(eval.Rubric) != len(RubricDimensions) {
+				t.Fatalf("Rubric has %d dimensions, want %d", len(eval.Rubric), len(RubricDimensions))
+			}
+			for i, r := range eval.Rubric {
+				if r.Dimension != RubricDimensions[i] {
+					t.Errorf("Rubric[%d] = %q, want %q", i, r.Dimension, RubricDimensions[i])
+				}
+			}
+			if eval.Feedback != "Solid answer." {
+				t.Errorf("Feedback = %q", eval.Feedback)
+			}
+			if strings.Join(eval.KeyPointsCovered, ",") != "Indexes" || strings.Join(eval.KeyPointsMissed, ",") != "Caching" {
+				t.Errorf("key points covered %q, missed %q; want them in their listed form", eval.KeyPointsCovered, eval.KeyPointsMissed)
+			}
+		})
+	}
+}
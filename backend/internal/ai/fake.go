@@ -0,0 +1,304 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+)
+
+// FakeFixtures scripts the output of FakeProvider. Any field left empty
+// falls back to the built-in defaults.
+type FakeFixtures struct {
+	Questions     []GeneratedQuestion `json:"questions"`
+	Evaluations   []Evaluation        `json:"evaluations"`
+	FinalFeedback *FinalFeedback      `json:"final_feedback"`
+	Profile       *Profile            `json:"profile"`
+}
+
+// fakePromptVersion is recorded as the prompt version of fake output.
+const fakePromptVersion = "fake"
+
+// FakeProvider is an offline Provider that returns deterministic, scripted
+// output. It lets the backend run without any API key or network access.
+type FakeProvider struct {
+	fixtures FakeFixtures
+}
+
+var defaultFakeQuestions = []GeneratedQuestion{
+	{
+		Text: "Walk me through a recent project you worked on as a %s.", Type: "technical", Topics: []string{"experience"}, Difficulty: "easy",
+		ReferenceAnswer: "Describes the goal, their own role, the main technical decisions and the outcome.",
+		KeyPoints:       []string{"goal", "role", "outcome"},
+	},
+	{
+		Text: "Describe a time you disagreed with a teammate and how you resolved it.", Type: "behavioral", Topics: []string{"teamwork", "conflict"}, Difficulty: "medium",
+		ReferenceAnswer: "Uses a concrete situation, listens to the other view, finds common ground and reflects on the result.",
+		KeyPoints:       []string{"situation", "listened", "result"},
+	},
+	{
+		Text: "What trade-offs do you consider when designing a new feature as a %s?", Type: "technical", Topics: []string{"design"}, Difficulty: "medium",
+		ReferenceAnswer: "Weighs performance, maintainability, cost and delivery time against the user need.",
+		KeyPoints:       []string{"performance", "maintainability", "cost"},
+	},
+	{
+		Text: "Write a program that reads a string from stdin and prints its first non-repeating character, or \"none\" if every character repeats.", Type: "coding", Topics: []string{"strings", "hash maps"}, Difficulty: "medium",
+		ReferenceAnswer: "Count characters in a map in one pass, then return the first character with count one in a second pass; O(n) time.",
+		KeyPoints:       []string{"map", "two passes", "O(n)"},
+		TestCases: []TestCase{
+			{Input: "leetcode\n", ExpectedOutput: "l\n"},
+			{Input: "aabbcd\n", ExpectedOutput: "c\n"},
+			{Input: "aabb\n", ExpectedOutput: "none\n"},
+		},
+	},
+	{
+		Text: "Tell me about a mistake you made and what you learned from it.", Type: "behavioral", Topics: []string{"growth"}, Difficulty: "easy",
+		ReferenceAnswer: "Owns a real mistake, explains its impact, how it was fixed and what changed afterwards.",
+		KeyPoints:       []string{"impact", "fixed", "learned"},
+	},
+}
+
+// NewFakeProvider creates a FakeProvider. If fixturesPath is non-empty the
+// fixtures are loaded from that JSON file.
+func NewFakeProvider(fixturesPath string) (*FakeProvider, error) {
+	p := &FakeProvider{}
+	if fixturesPath == "" {
+		return p, nil
+	}
+
+	data, err := os.ReadFile(fixturesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake AI fixtures: %w", err)
+	}
+	if err := json.Unmarshal(data, &p.fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse fake AI fixtures: %w", err)
+	}
+
+	return p, nil
+}
+
+func (p *FakeProvider) GenerateQuestions(ctx context.Context, req QuestionRequest) ([]GeneratedQuestion, error) {
+	source := p.fixtures.Questions
+	if len(source) == 0 {
+		source = defaultFakeQuestions
+	}
+
+	// Continue where earlier questions left off
+	questions := make([]GeneratedQuestion, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		n := (len(req.Asked) + i) % len(source)
+		q := source[n]
+		if n == 0 && len(p.fixtures.Questions) == 0 && req.Profile != nil && len(req.Profile.Projects) > 0 {
+			// Ask about the candidate's own project instead
+			q.Text = fmt.Sprintf("Walk me through %s, the project on your resume.", req.Profile.Projects[0].Name)
+		}
+		if strings.Contains(q.Text, "%s") {
+			q.Text = fmt.Sprintf(q.Text, req.Position)
+		}
+		if q.Type == "" {
+			q.Type = "technical"
+		}
+		if q.Difficulty == "" {
+			q.Difficulty = req.Difficulty
+		}
+		if q.Topics == nil {
+			q.Topics = []string{}
+		}
+		q.PromptVersion = fakePromptVersion
+		questions = append(questions, q)
+	}
+
+	return questions, nil
+}
+
+// EvaluateAnswer picks a scripted evaluation by hashing the question and
+// answer, so the same answer always receives the same score. Without
+// fixtures the score grows with the length of the answer and is scaled by
+// the share of passing tests when the answer's code was run. A key point
+// counts as covered when the answer contains it verbatim.
+func (p *FakeProvider) EvaluateAnswer(ctx context.Context, req EvaluationRequest) (*Evaluation, error) {
+	var eval Evaluation
+	if len(p.fixtures.Evaluations) > 0 {
+		h := fnv.New32a()
+		h.Write([]byte(req.Question + "\x00" + req.Answer))
+		eval = p.fixtures.Evaluations[int(h.Sum32()%uint32(len(p.fixtures.Evaluations)))]
+		if len(eval.Rubric) == 0 {
+			eval.Rubric = fakeRubric(eval.Score)
+		}
+	} else {
+		words := len(strings.Fields(req.Answer))
+		score := 2.0 + float64(words)/10
+		if score > 10 {
+			score = 10
+		}
+		feedback := fmt.Sprintf("Fake evaluation: your answer had %d words.", words)
+		if len(req.TestResults) > 0 {
+			passed := 0
+			for _, t := range req.TestResults {
+				if t.Passed {
+					passed++
+				}
+			}
+			score = score * float64(passed) / float64(len(req.TestResults))
+			feedback += fmt.Sprintf(" Your code passed %d of %d tests.", passed, len(req.TestResults))
+		}
+		eval = Evaluation{
+			Score:      score,
+			Feedback:   feedback,
+			Strengths:  []string{},
+			Weaknesses: []string{},
+			Rubric:     fakeRubric(score),
+		}
+	}
+
+	eval.KeyPointsCovered, eval.KeyPointsMissed = []string{}, []string{}
+	answer := strings.ToLower(req.Answer)
+	for _, kp := range req.KeyPoints {
+		if strings.Contains(answer, strings.ToLower(kp)) {
+			eval.KeyPointsCovered = append(eval.KeyPointsCovered, kp)
+		} else {
+			eval.KeyPointsMissed = append(eval.KeyPointsMissed, kp)
+		}
+	}
+	eval.PromptVersion = fakePromptVersion
+
+	return &eval, nil
+}
+
+// fakeRubric gives every rubric dimension the same score.
+func fakeRubric(score float64) []RubricScore {
+	rubric := make([]RubricScore, 0, len(RubricDimensions))
+	for _, dim := range RubricDimensions {
+		rubric = append(rubric, RubricScore{Dimension: dim, Score: score, Justification: "Fake evaluation."})
+	}
+	return rubric
+}
+
+// GenerateFinalFeedback lists answers scoring 7 or more as strengths and
+// answers scoring under 5, or not scored, as improvement areas. The
+// recommendation follows the average score.
+func (p *FakeProvider) GenerateFinalFeedback(ctx context.Context, req FinalFeedbackRequest) (*FinalFeedback, error) {
+	if p.fixtures.FinalFeedback != nil {
+		feedback := *p.fixtures.FinalFeedback
+		feedback.PromptVersion = fakePromptVersion
+		return &feedback, nil
+	}
+
+	feedback := &FinalFeedback{
+		Summary: fmt.Sprintf("Fake final feedback: you answered %d questions for the %s position with an average score of %.2f/10.",
+			len(req.Transcript), req.Position, req.AverageScore),
+		Strengths:     []string{},
+		Improvements:  []string{},
+		PromptVersion: fakePromptVersion,
+	}
+	for _, e := range req.Transcript {
+		switch {
+		case e.Score == nil || *e.Score < 5:
+			feedback.Improvements = append(feedback.Improvements, e.Question)
+		case *e.Score >= 7:
+			feedback.Strengths = append(feedback.Strengths, e.Question)
+		}
+	}
+	switch {
+	case req.AverageScore >= 7.5:
+		feedback.Recommendation = "hire"
+	case req.AverageScore >= 5:
+		feedback.Recommendation = "consider"
+	default:
+		feedback.Recommendation = "no_hire"
+	}
+
+	return feedback, nil
+}
+
+// GenerateFollowUp asks the candidate to elaborate on answers that scored
+// under 5 or were not scored.
+func (p *FakeProvider) GenerateFollowUp(ctx context.Context, req FollowUpRequest) (*GeneratedQuestion, error) {
+	if req.Score != nil && *req.Score >= 5 {
+		return nil, nil
+	}
+
+	return &GeneratedQuestion{
+		Text:            fmt.Sprintf("Can you go into more detail on your answer to: %s", req.Question),
+		Type:            req.Type,
+		Topics:          []string{"follow-up"},
+		Difficulty:      "medium",
+		ReferenceAnswer: "Expands on the original answer with concrete details, reasoning and an example.",
+		KeyPoints:       []string{"details", "reasoning", "example"},
+		PromptVersion:   fakePromptVersion,
+	}, nil
+}
+
+// GenerateHint reveals one more key point with every hint.
+func (p *FakeProvider) GenerateHint(ctx context.Context, req HintRequest) (*Hint, error) {
+	level := len(req.Previous) + 1
+	if len(req.KeyPoints) == 0 {
+		return &Hint{Text: fmt.Sprintf("Fake hint %d: break the question into smaller parts.", level), PromptVersion: fakePromptVersion}, nil
+	}
+	if level > len(req.KeyPoints) {
+		level = len(req.KeyPoints)
+	}
+	return &Hint{
+		Text:          fmt.Sprintf("Fake hint %d: think about %s.", len(req.Previous)+1, strings.Join(req.KeyPoints[:level], ", ")),
+		PromptVersion: fakePromptVersion,
+	}, nil
+}
+
+// GenerateModelAnswer returns the question's reference answer.
+func (p *FakeProvider) GenerateModelAnswer(ctx context.Context, req ModelAnswerRequest) (*ModelAnswer, error) {
+	answer := req.ReferenceAnswer
+	if answer == "" {
+		answer = "Fake model answer."
+	}
+	return &ModelAnswer{
+		Answer: answer,
+		Explanation: fmt.Sprintf("Fake comparison: your answer had %d words, the model answer has %d.",
+			len(strings.Fields(req.Answer)), len(strings.Fields(answer))),
+		PromptVersion: fakePromptVersion,
+	}, nil
+}
+
+// ExtractProfile reads skills from a "Skills:" line or the bullet points
+// under a "Skills" heading, and projects from the bullet points under a
+// "Projects" heading, named up to any ": ".
+func (p *FakeProvider) ExtractProfile(ctx context.Context, req ProfileRequest) (*Profile, error) {
+	if p.fixtures.Profile != nil {
+		profile := *p.fixtures.Profile
+		profile.PromptVersion = fakePromptVersion
+		return &profile, nil
+	}
+
+	profile := &Profile{Skills: []string{}, Projects: []ProfileProject{}, PromptVersion: fakePromptVersion}
+	section := ""
+	for _, line := range strings.Split(req.Resume, "\n") {
+		line = strings.TrimSpace(strings.ReplaceAll(line, "**", ""))
+		if bullet := strings.TrimLeft(line, "-*• "); bullet != line {
+			switch section {
+			case "skills":
+				profile.Skills = append(profile.Skills, strings.Split(bullet, ",")...)
+			case "projects":
+				name, summary, _ := strings.Cut(bullet, ": ")
+				profile.Projects = append(profile.Projects, ProfileProject{Name: name, Summary: summary, Technologies: []string{}})
+			}
+			continue
+		}
+
+		title, rest, _ := strings.Cut(strings.TrimLeft(line, "# "), ":")
+		switch strings.ToLower(strings.TrimSpace(title)) {
+		case "skills":
+			section = "skills"
+			profile.Skills = append(profile.Skills, strings.Split(rest, ",")...)
+		case "projects":
+			section = "projects"
+		default:
+			if strings.HasPrefix(line, "#") {
+				section = ""
+			}
+		}
+	}
+	profile.Skills = cleanStrings(profile.Skills)
+
+	return profile, nil
+}
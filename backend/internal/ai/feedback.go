@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Recommendations are the hiring outcomes final feedback can recommend.
+var Recommendations = []string{"hire", "consider", "no_hire"}
+
+// TranscriptEntry is one question of a finished interview together with
+// the candidate's answer and how it was graded. Score is nil when the
+// answer was not scored.
+type TranscriptEntry struct {
+	Question string
+	Type     string
+	Answer   string
+	Feedback string
+	Score    *float64
+}
+
+// FinalFeedbackRequest is everything the final feedback is based on.
+type FinalFeedbackRequest struct {
+	Position     string
+	Difficulty   string
+	AverageScore float64
+	Transcript   []TranscriptEntry
+}
+
+// FinalFeedback is the structured summary of a whole interview.
+type FinalFeedback struct {
+	Summary        string   `json:"summary"`
+	Strengths      []string `json:"strengths"`
+	Improvements   []string `json:"improvements"`
+	Recommendation string   `json:"recommendation"` // hire, consider, no_hire
+
+	// PromptVersion identifies the template that produced the feedback
+	PromptVersion string `json:"-"`
+}
+
+const finalFeedbackSchema = `{
+  "summary": string, 3-4 sentences assessing the overall performance,
+  "strengths": array of short strings, each grounded in a specific answer,
+  "improvements": array of short strings, each grounded in a specific answer,
+  "recommendation": one of "hire", "consider", "no_hire"
+}`
+
+// parseFinalFeedback strictly decodes and validates a model reply against
+// finalFeedbackSchema.
+func parseFinalFeedback(response string) (*FinalFeedback, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(extractJSON(response))))
+	dec.DisallowUnknownFields()
+
+	var feedback FinalFeedback
+	if err := dec.Decode(&feedback); err != nil {
+		return nil, fmt.Errorf("response does not match schema: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON object")
+	}
+
+	feedback.Summary = strings.TrimSpace(feedback.Summary)
+	if feedback.Summary == "" {
+		return nil, fmt.Errorf("summary is missing or empty")
+	}
+	if feedback.Strengths == nil || feedback.Improvements == nil {
+		return nil, fmt.Errorf("strengths and improvements are required")
+	}
+	if !slices.Contains(Recommendations, feedback.Recommendation) {
+		return nil, fmt.Errorf("recommendation %q is not one of %s", feedback.Recommendation, strings.Join(Recommendations, ", "))
+	}
+
+	return &feedback, nil
+}
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// FollowUpRequest describes an answer that may deserve a probing
+// follow-up question.
+type FollowUpRequest struct {
+	Position string
+	Question string
+	Type     string
+	Answer   string
+	Feedback string
+	Score    *float64 // nil when the answer was not scored
+}
+
+// followUpTypes are the question types a follow-up may have. Follow-ups
+// carry no test cases, dataset or answer key, so the types that need one
+// are left out.
+var followUpTypes = []string{"technical", "behavioral", "system_design"}
+
+const followUpSchema = `{
+  "follow_up": null if the answer is complete and unambiguous, otherwise an object:
+  {
+    "question": string, the follow-up question text,
+    "type": one of "technical", "behavioral", "system_design",
+    "topics": array of 1-3 short topic tags,
+    "difficulty": one of "easy", "medium", "hard" (your own estimate),
+    "reference_answer": string, a concise model answer,
+    "key_points": array of 3-5 short points a strong answer must cover
+  }
+}`
+
+// parseFollowUp strictly decodes and validates a model reply against
+// followUpSchema. It returns nil when the model decided no follow-up is
+// needed.
+func parseFollowUp(ctx context.Context, response string) (*GeneratedQuestion, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(extractJSON(response))))
+	dec.DisallowUnknownFields()
+
+	var reply struct {
+		FollowUp json.RawMessage `json:"follow_up"`
+	}
+	if err := dec.Decode(&reply); err != nil {
+		return nil, fmt.Errorf("response does not match schema: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON object")
+	}
+	if len(reply.FollowUp) == 0 {
+		return nil, fmt.Errorf("follow_up is missing")
+	}
+	if string(reply.FollowUp) == "null" {
+		return nil, nil
+	}
+
+	dec = json.NewDecoder(bytes.NewReader(reply.FollowUp))
+	dec.DisallowUnknownFields()
+	var q GeneratedQuestion
+	if err := dec.Decode(&q); err != nil {
+		return nil, fmt.Errorf("follow_up does not match schema: %w", err)
+	}
+	if err := validateGeneratedQuestion(&q); err != nil {
+		return nil, fmt.Errorf("follow_up %w", err)
+	}
+	if !slices.Contains(followUpTypes, q.Type) {
+		return nil, fmt.Errorf("follow_up has type %q, which a follow-up cannot have", q.Type)
+	}
+	if err := validateTypeFields(ctx, &q); err != nil {
+		return nil, fmt.Errorf("follow_up %w", err)
+	}
+
+	return &q, nil
+}
@@ -0,0 +1,87 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseFollowUp(t *testing.T) {
+	const question = `"question": "Why?", "topics": ["design"], "difficulty": "Medium",
+		"reference_answer": "Because.", "key_points": ["trade-offs"]`
+
+	tests := []struct {
+		name     string
+		reply    string
+		wantNone bool
+		wantErr  string
+	}{
+		{
+			name:     "no follow-up",
+			reply:    `{"follow_up": null}`,
+			wantNone: true,
+		},
+		{
+			name:  "technical",
+			reply: `{"follow_up": {` + question + `, "type": "technical"}}`,
+		},
+		{
+			name: "fields of other types are dropped",
+			reply: `{"follow_up": {` + question + `, "type": "System_Design", "options": ["a", "b"], "correct_options": [0],
+				"test_cases": [{"input": "1", "expected_output": "1"}], "accepted_answers": ["x"]}}`,
+		},
+		{
+			name:    "coding",
+			reply:   `{"follow_up": {` + question + `, "type": "coding", "test_cases": [{"input": "1", "expected_output": "1"}]}}`,
+			wantErr: `type "coding", which a follow-up cannot have`,
+		},
+		{
+			name:    "quiz",
+			reply:   `{"follow_up": {` + question + `, "type": "single_choice", "options": ["a", "b"], "correct_options": [0]}}`,
+			wantErr: `type "single_choice", which a follow-up cannot have`,
+		},
+		{
+			name:    "missing follow_up",
+			reply:   `{}`,
+			wantErr: "follow_up is missing",
+		},
+		{
+			name:    "incomplete question",
+			reply:   `{"follow_up": {"question": "Why?", "type": "technical", "difficulty": "easy"}}`,
+			wantErr: "has no reference answer",
+		},
+		{
+			name:    "unknown field",
+			reply:   `{"follow_up": {` + question + `, "type": "technical", "hint": "x"}}`,
+			wantErr: "does not match schema",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := parseFollowUp(context.Background(), tt.reply)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNone {
+				if q != nil {
+					t.Errorf("parseFollowUp() = %+v, want no follow-up", q)
+				}
+				return
+			}
+
+			if q == nil || q.Text != "Why?" || q.Difficulty != "medium" {
+				t.Fatalf("parseFollowUp() = %+v, want the normalized question", q)
+			}
+			if q.Options != nil || q.CorrectOptions != nil || q.TestCases != nil || q.AcceptedAnswers != nil || q.Dataset != nil {
+				t.Errorf("kept fields of other question types: %+v", q)
+			}
+		})
+	}
+}
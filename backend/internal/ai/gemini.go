@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// GeminiClient is a Completer backed by Google's Gemini API.
+type GeminiClient struct {
+	client *genai.Client
+	model  *genai.GenerativeModel
+}
+
+func NewGeminiClient(apiKey, modelName string) (*GeminiClient, error) {
+	ctx := context.Background()
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Gemini: %w", err)
+	}
+
+	model := client.GenerativeModel(modelName)
+
+	return &GeminiClient{
+		client: client,
+		model:  model,
+	}, nil
+}
+
+func (c *GeminiClient) Complete(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", ErrEmptyResponse
+	}
+
+	return fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0]), nil
+}
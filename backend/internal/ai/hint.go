@@ -0,0 +1,52 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// HintRequest asks for the next hint on a question. Each hint should be
+// stronger than the previous ones without giving the answer away.
+type HintRequest struct {
+	Question        string
+	ReferenceAnswer string
+	KeyPoints       []string
+	Previous        []string // hints already given, weakest first
+	MaxHints        int
+}
+
+// Hint is the next hint on a question.
+type Hint struct {
+	Text string `json:"hint"`
+
+	// PromptVersion identifies the template that produced the hint
+	PromptVersion string `json:"-"`
+}
+
+const hintSchema = `{
+  "hint": string, the hint, one or two sentences
+}`
+
+// parseHint strictly decodes and validates a model reply against
+// hintSchema.
+func parseHint(response string) (*Hint, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(extractJSON(response))))
+	dec.DisallowUnknownFields()
+
+	var hint Hint
+	if err := dec.Decode(&hint); err != nil {
+		return nil, fmt.Errorf("response does not match schema: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON object")
+	}
+
+	hint.Text = strings.TrimSpace(hint.Text)
+	if hint.Text == "" {
+		return nil, fmt.Errorf("hint is missing or empty")
+	}
+
+	return &hint, nil
+}
@@ -0,0 +1,41 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseHint(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    string
+		wantErr string
+	}{
+		{name: "valid", reply: `{"hint": " Think about indexes. "}`, want: "Think about indexes."},
+		{name: "code fence", reply: "```json\n{\"hint\": \"Think about indexes.\"}\n```", want: "Think about indexes."},
+		{name: "plain text", reply: "Think about indexes.", wantErr: "does not match schema"},
+		{name: "unknown field", reply: `{"hint": "Think about indexes.", "level": 2}`, wantErr: "does not match schema"},
+		{name: "trailing data", reply: `{"hint": "Think about indexes."} {}`, wantErr: "unexpected data"},
+		{name: "missing hint", reply: `{}`, wantErr: "hint is missing"},
+		{name: "blank hint", reply: `{"hint": "  "}`, wantErr: "hint is missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hint, err := parseHint(tt.reply)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hint.Text != tt.want {
+				t.Errorf("Text = %q, want %q", hint.Text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// candidateTag delimits candidate-written text inside prompts. Prompts
+// tell the model to treat everything between the tags as data. Every
+// rendered prompt appends its own random nonce to the tag, so candidate
+// text cannot know the tag that closes its block.
+const candidateTag = "candidate_answer"
+
+// candidateTagPattern matches attempts to open or close the delimiter,
+// with or without a nonce, from inside candidate text.
+var candidateTagPattern = regexp.MustCompile(`(?i)<\s*/?\s*` + candidateTag + `[\w-]*\s*>`)
+
+// candidateNoncePattern matches the nonce newCandidateTag appends.
+var candidateNoncePattern = regexp.MustCompile(candidateTag + `_[0-9a-f]{16}`)
+
+// newCandidateTag returns candidateTag with a random nonce appended.
+func newCandidateTag() (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return candidateTag + "_" + hex.EncodeToString(nonce), nil
+}
+
+// delimitCandidate wraps candidate text in tag, removing any delimiter
+// tags from the text so it cannot break out of the block. Removal repeats
+// until nothing changes, since removing one tag can join the text around
+// it into another.
+func delimitCandidate(tag, text string) string {
+	for {
+		stripped := candidateTagPattern.ReplaceAllString(text, "")
+		if stripped == text {
+			break
+		}
+		text = stripped
+	}
+	return "<" + tag + ">\n" + text + "\n</" + tag + ">"
+}
+
+// injectionPatterns are phrasings commonly used to override a model's
+// instructions, keyed by a short description.
+var injectionPatterns = []struct {
+	reason  string
+	pattern *regexp.Regexp
+}{
+	{"asks to ignore instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,40}\b(instructions?|prompts?|rules|guidelines)\b`)},
+	{"addresses the grader", regexp.MustCompile(`(?i)\b(you are now|pretend to be|dear (grader|evaluator|ai)|note to the (grader|evaluator|ai))\b`)},
+	{"dictates a score", regexp.MustCompile(`(?i)\b(give|award|assign|output|return)\b.{0,30}\b(score|rating|grade|marks?)\b.{0,20}\b(10|ten|full|perfect)\b|"score"\s*:\s*10\b`)},
+	{"contains chat role markers", regexp.MustCompile(`(?im)(<\|im_(start|end)\|>|^\s*(system|assistant)\s*:|\[/?INST\]|###\s*(system|instruction))`)},
+	{"tries to close the answer block", candidateTagPattern},
+}
+
+// DetectInjection returns a short description of every prompt-injection
+// signal found in candidate text, or nil if none was found.
+func DetectInjection(text string) []string {
+	var reasons []string
+	for _, p := range injectionPatterns {
+		if p.pattern.MatchString(text) {
+			reasons = append(reasons, p.reason)
+		}
+	}
+	return reasons
+}
+
+// InjectionReviewReason explains why an answer was routed to human review
+// for possible prompt injection.
+func InjectionReviewReason(reasons []string) string {
+	return "possible prompt injection: " + strings.Join(reasons, ", ")
+}
@@ -0,0 +1,291 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ErrEmptyResponse is returned by a Completer when the model replied
+// without any content.
+var ErrEmptyResponse = errors.New("no response from AI")
+
+// ErrInvalidResponse is returned when the model's reply still did not match
+// the requested JSON schema after all repair attempts.
+var ErrInvalidResponse = errors.New("invalid response from AI")
+
+// maxRepairAttempts bounds how many times the model is asked for a reply
+// that matches the requested schema.
+const maxRepairAttempts = 3
+
+// Completer sends a single prompt to a text model and returns its reply.
+// Gemini, OpenAI-compatible servers, etc. each provide one.
+type Completer interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// LLMProvider implements Provider for any Completer by rendering the
+// interview prompt templates and parsing the model's replies.
+type LLMProvider struct {
+	llm     Completer
+	prompts *Prompts
+}
+
+func NewLLMProvider(llm Completer, prompts *Prompts) *LLMProvider {
+	return &LLMProvider{llm: llm, prompts: prompts}
+}
+
+func (p *LLMProvider) GenerateQuestions(ctx context.Context, req QuestionRequest) ([]GeneratedQuestion, error) {
+	tmpl := p.prompts.Get(PromptQuestions)
+	prompt, err := tmpl.Render(map[string]interface{}{
+		"Position":   req.Position,
+		"Difficulty": req.Difficulty,
+		"Count":      req.Count,
+		"Asked":      req.Asked,
+		"Profile":    formatProfile(req.Profile),
+		"Schema":     questionsSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var questions []GeneratedQuestion
+	err = p.completeJSON(ctx, prompt, questionsSchema, func(response string) error {
+		var err error
+		questions, err = parseGeneratedQuestions(ctx, response)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate questions: %w", err)
+	}
+
+	for i := range questions {
+		questions[i].PromptVersion = tmpl.Version
+	}
+
+	return questions, nil
+}
+
+func (p *LLMProvider) EvaluateAnswer(ctx context.Context, req EvaluationRequest) (*Evaluation, error) {
+	tmpl := p.prompts.Get(PromptEvaluation)
+	prompt, err := tmpl.Render(map[string]interface{}{
+		"Question":        req.Question,
+		"Answer":          req.Answer,
+		"ReferenceAnswer": req.ReferenceAnswer,
+		"KeyPoints":       req.KeyPoints,
+		"Hints":           req.Hints,
+		"Language":        req.Language,
+		"TestResults":     req.TestResults,
+		"Diagnostics":     req.Diagnostics,
+		"Diagrams":        req.Diagrams,
+		"QueryCheck":      req.QueryCheck,
+		"Schema":          evaluationSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var eval *Evaluation
+	err = p.completeJSON(ctx, prompt, evaluationSchema, func(response string) error {
+		var err error
+		eval, err = parseEvaluation(response, req.KeyPoints)
+		return err
+	})
+	if errors.Is(err, ErrInvalidResponse) {
+		return nil, ErrUnscored
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate answer: %w", err)
+	}
+
+	eval.PromptVersion = tmpl.Version
+	return eval, nil
+}
+
+func (p *LLMProvider) GenerateFinalFeedback(ctx context.Context, req FinalFeedbackRequest) (*FinalFeedback, error) {
+	tmpl := p.prompts.Get(PromptFinalFeedback)
+	prompt, err := tmpl.Render(map[string]interface{}{
+		"Position":     req.Position,
+		"Difficulty":   req.Difficulty,
+		"AverageScore": req.AverageScore,
+		"Transcript":   req.Transcript,
+		"Schema":       finalFeedbackSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var feedback *FinalFeedback
+	err = p.completeJSON(ctx, prompt, finalFeedbackSchema, func(response string) error {
+		var err error
+		feedback, err = parseFinalFeedback(response)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate final feedback: %w", err)
+	}
+
+	feedback.PromptVersion = tmpl.Version
+	return feedback, nil
+}
+
+func (p *LLMProvider) GenerateFollowUp(ctx context.Context, req FollowUpRequest) (*GeneratedQuestion, error) {
+	tmpl := p.prompts.Get(PromptFollowUp)
+	prompt, err := tmpl.Render(map[string]interface{}{
+		"Position": req.Position,
+		"Question": req.Question,
+		"Type":     req.Type,
+		"Answer":   req.Answer,
+		"Feedback": req.Feedback,
+		"Score":    req.Score,
+		"Schema":   followUpSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var question *GeneratedQuestion
+	err = p.completeJSON(ctx, prompt, followUpSchema, func(response string) error {
+		var err error
+		question, err = parseFollowUp(ctx, response)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate follow-up question: %w", err)
+	}
+
+	if question != nil {
+		question.PromptVersion = tmpl.Version
+	}
+	return question, nil
+}
+
+func (p *LLMProvider) GenerateHint(ctx context.Context, req HintRequest) (*Hint, error) {
+	tmpl := p.prompts.Get(PromptHint)
+	prompt, err := tmpl.Render(map[string]interface{}{
+		"Question":        req.Question,
+		"ReferenceAnswer": req.ReferenceAnswer,
+		"KeyPoints":       req.KeyPoints,
+		"Previous":        req.Previous,
+		"MaxHints":        req.MaxHints,
+		"Schema":          hintSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var hint *Hint
+	err = p.completeJSON(ctx, prompt, hintSchema, func(response string) error {
+		var err error
+		hint, err = parseHint(response)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate hint: %w", err)
+	}
+
+	hint.PromptVersion = tmpl.Version
+	return hint, nil
+}
+
+func (p *LLMProvider) GenerateModelAnswer(ctx context.Context, req ModelAnswerRequest) (*ModelAnswer, error) {
+	tmpl := p.prompts.Get(PromptModelAnswer)
+	prompt, err := tmpl.Render(map[string]interface{}{
+		"Question":        req.Question,
+		"ReferenceAnswer": req.ReferenceAnswer,
+		"KeyPoints":       req.KeyPoints,
+		"Answer":          req.Answer,
+		"Feedback":        req.Feedback,
+		"Schema":          modelAnswerSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var answer *ModelAnswer
+	err = p.completeJSON(ctx, prompt, modelAnswerSchema, func(response string) error {
+		var err error
+		answer, err = parseModelAnswer(response)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate model answer: %w", err)
+	}
+
+	answer.PromptVersion = tmpl.Version
+	return answer, nil
+}
+
+func (p *LLMProvider) ExtractProfile(ctx context.Context, req ProfileRequest) (*Profile, error) {
+	tmpl := p.prompts.Get(PromptProfile)
+	prompt, err := tmpl.Render(map[string]interface{}{
+		"Resume":      req.Resume,
+		"MaxSkills":   maxProfileSkills,
+		"MaxProjects": maxProfileProjects,
+		"Schema":      profileSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var profile *Profile
+	err = p.completeJSON(ctx, prompt, profileSchema, func(response string) error {
+		var err error
+		profile, err = parseProfile(response)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract profile: %w", err)
+	}
+
+	profile.PromptVersion = tmpl.Version
+	return profile, nil
+}
+
+// completeJSON sends prompt and hands the reply to parse. When parse
+// rejects the reply the model is sent the original prompt again, followed
+// by its mistake, and asked to repair it, up to maxRepairAttempts times in
+// total.
+func (p *LLMProvider) completeJSON(ctx context.Context, prompt, schema string, parse func(string) error) error {
+	request := prompt
+	for attempt := 1; attempt <= maxRepairAttempts; attempt++ {
+		response, err := p.llm.Complete(ctx, request)
+		if errors.Is(err, ErrEmptyResponse) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = parse(response)
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("Invalid AI response (attempt %d/%d): %v", attempt, maxRepairAttempts, err)
+		request, err = p.prompts.Get(PromptRepair).Render(map[string]interface{}{
+			"Prompt":   prompt,
+			"Error":    err.Error(),
+			"Response": response,
+			"Schema":   schema,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return ErrInvalidResponse
+}
+
+// extractJSON strips surrounding whitespace and markdown code fences.
+func extractJSON(response string) string {
+	s := strings.TrimSpace(response)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```")
+		s = strings.TrimPrefix(s, "json")
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	}
+	return strings.TrimSpace(s)
+}
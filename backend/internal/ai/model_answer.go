@@ -0,0 +1,59 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ModelAnswerRequest is an evaluated answer for which an exemplary answer
+// is wanted.
+type ModelAnswerRequest struct {
+	Question        string
+	ReferenceAnswer string
+	KeyPoints       []string
+	Answer          string
+	Feedback        string
+}
+
+// ModelAnswer is an exemplary answer and how the candidate's answer
+// differs from it.
+type ModelAnswer struct {
+	Answer      string `json:"model_answer"`
+	Explanation string `json:"explanation"`
+
+	// PromptVersion identifies the template that produced the answer
+	PromptVersion string `json:"-"`
+}
+
+const modelAnswerSchema = `{
+  "model_answer": string, an exemplary answer to the question,
+  "explanation": string, 2-3 sentences on how the candidate's answer differs from the model answer
+}`
+
+// parseModelAnswer strictly decodes and validates a model reply against
+// modelAnswerSchema.
+func parseModelAnswer(response string) (*ModelAnswer, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(extractJSON(response))))
+	dec.DisallowUnknownFields()
+
+	var answer ModelAnswer
+	if err := dec.Decode(&answer); err != nil {
+		return nil, fmt.Errorf("response does not match schema: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON object")
+	}
+
+	answer.Answer = strings.TrimSpace(answer.Answer)
+	answer.Explanation = strings.TrimSpace(answer.Explanation)
+	if answer.Answer == "" {
+		return nil, fmt.Errorf("model_answer is missing or empty")
+	}
+	if answer.Explanation == "" {
+		return nil, fmt.Errorf("explanation is missing or empty")
+	}
+
+	return &answer, nil
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OllamaClient is a Completer for a local Ollama daemon's /api/chat
+// endpoint. Every call logs the model that answered and its latency.
+type OllamaClient struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type ollamaChatResponse struct {
+	Model   string      `json:"model"`
+	Message chatMessage `json:"message"`
+	Error   string      `json:"error"`
+}
+
+// NewOllamaClient creates a client for the daemon at baseURL
+// (e.g. "http://localhost:11434").
+func NewOllamaClient(baseURL, model string) *OllamaClient {
+	return &OllamaClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		// Local models can be slow to load on first use
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (c *OllamaClient) Complete(ctx context.Context, prompt string) (string, error) {
+	body, err := json.Marshal(ollamaChatRequest{
+		Model:    c.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+		Stream:   false,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed ollamaChatResponse
+	if err := json.Unmarshal(data, &parsed); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid ollama response: %w", err)
+	}
+
+	model := parsed.Model
+	if model == "" {
+		model = c.model
+	}
+	log.Printf("Ollama call: model=%s latency=%s status=%d", model, latency.Round(time.Millisecond), resp.StatusCode)
+
+	if resp.StatusCode != http.StatusOK {
+		msg := parsed.Error
+		if msg == "" {
+			msg = strings.TrimSpace(string(data))
+		}
+		return "", statusError(resp.StatusCode, "ollama returned %d: %s", resp.StatusCode, msg)
+	}
+	if parsed.Message.Content == "" {
+		return "", ErrEmptyResponse
+	}
+
+	return parsed.Message.Content, nil
+}
@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient is a Completer for any server exposing an OpenAI-compatible
+// /v1/chat/completions endpoint (OpenAI, llama.cpp, vLLM, local mocks, ...).
+type OpenAIClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// NewOpenAIClient creates a client for baseURL, which should include the
+// API version prefix (e.g. "http://localhost:8000/v1"). apiKey may be empty
+// for servers that do not require authentication.
+func NewOpenAIClient(baseURL, apiKey, model string) *OpenAIClient {
+	return &OpenAIClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+func (c *OpenAIClient) Complete(ctx context.Context, prompt string) (string, error) {
+	body, err := json.Marshal(openAIChatRequest{
+		Model:    c.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(resp.StatusCode, "chat completions returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var parsed openAIChatResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return "", fmt.Errorf("invalid chat completions response: %w", err)
+	}
+	if len(parsed.Choices) == 0 || parsed.Choices[0].Message.Content == "" {
+		return "", ErrEmptyResponse
+	}
+
+	return parsed.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProfileRequest is a candidate's resume, as plain text, to extract a
+// profile from.
+type ProfileRequest struct {
+	Resume string
+}
+
+// Profile is the experience a candidate claims in their resume.
+type Profile struct {
+	Skills   []string         `json:"skills"`
+	Projects []ProfileProject `json:"projects"`
+
+	// PromptVersion identifies the template that produced the profile
+	PromptVersion string `json:"-"`
+}
+
+// ProfileProject is a project listed in a resume.
+type ProfileProject struct {
+	Name         string   `json:"name"`
+	Summary      string   `json:"summary"`
+	Technologies []string `json:"technologies"`
+}
+
+// Limits on an extracted profile, so a long resume cannot crowd the
+// question prompt.
+const (
+	maxProfileSkills   = 30
+	maxProfileProjects = 8
+)
+
+const profileSchema = `{
+  "skills": [string], the technical and professional skills the resume claims, most prominent first,
+  "projects": [
+    {
+      "name": string, the project's name or a short description,
+      "summary": string, one or two sentences on what was built and the candidate's role,
+      "technologies": [string], technologies used in the project
+    }
+  ]
+}`
+
+// parseProfile strictly decodes and validates a model reply against
+// profileSchema.
+func parseProfile(response string) (*Profile, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(extractJSON(response))))
+	dec.DisallowUnknownFields()
+
+	var profile Profile
+	if err := dec.Decode(&profile); err != nil {
+		return nil, fmt.Errorf("response does not match schema: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON object")
+	}
+
+	profile.Skills = cleanStrings(profile.Skills)
+	if len(profile.Skills) > maxProfileSkills {
+		profile.Skills = profile.Skills[:maxProfileSkills]
+	}
+	projects := make([]ProfileProject, 0, len(profile.Projects))
+	for i, p := range profile.Projects {
+		p.Name = strings.TrimSpace(p.Name)
+		p.Summary = strings.TrimSpace(p.Summary)
+		p.Technologies = cleanStrings(p.Technologies)
+		if p.Name == "" {
+			return nil, fmt.Errorf("project %d has no name", i+1)
+		}
+		projects = append(projects, p)
+	}
+	if len(projects) > maxProfileProjects {
+		projects = projects[:maxProfileProjects]
+	}
+	profile.Projects = projects
+
+	return &profile, nil
+}
+
+// cleanStrings trims values and drops empty ones and duplicates, ignoring
+// case.
+func cleanStrings(values []string) []string {
+	cleaned := make([]string, 0, len(values))
+	seen := make(map[string]bool)
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		key := strings.ToLower(v)
+		if v == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, v)
+	}
+	return cleaned
+}
+
+// formatProfile renders a profile for the question prompt, or "" if it
+// lists nothing.
+func formatProfile(profile *Profile) string {
+	if profile == nil || len(profile.Skills) == 0 && len(profile.Projects) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	if len(profile.Skills) > 0 {
+		fmt.Fprintf(&b, "Skills: %s\n", strings.Join(profile.Skills, ", "))
+	}
+	if len(profile.Projects) > 0 {
+		b.WriteString("Projects:\n")
+		for _, p := range profile.Projects {
+			fmt.Fprintf(&b, "- %s", p.Name)
+			if len(p.Technologies) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(p.Technologies, ", "))
+			}
+			if p.Summary != "" {
+				fmt.Fprintf(&b, ": %s", p.Summary)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
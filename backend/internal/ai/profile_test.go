@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProfile(t *testing.T) {
+	var manySkills, manyProjects, keptSkills []string
+	var keptProjects []ProfileProject
+	for i := 0; i < maxProfileSkills+5; i++ {
+		manySkills = append(manySkills, fmt.Sprintf(`"skill %d"`, i))
+		if i < maxProfileSkills {
+			keptSkills = append(keptSkills, fmt.Sprintf("skill %d", i))
+		}
+	}
+	for i := 0; i < maxProfileProjects+2; i++ {
+		manyProjects = append(manyProjects, fmt.Sprintf(`{"name": "project %d", "summary": "", "technologies": []}`, i))
+		if i < maxProfileProjects {
+			keptProjects = append(keptProjects, ProfileProject{Name: fmt.Sprintf("project %d", i), Technologies: []string{}})
+		}
+	}
+
+	tests := []struct {
+		name         string
+		reply        string
+		wantSkills   []string
+		wantProjects []ProfileProject
+		wantErr      string
+	}{
+		{
+			name: "cleaned",
+			reply: "```json\n" + `{"skills": [" Go ", "go", "", "SQL"], "projects": [
+				{"name": " Ledger ", "summary": " A payments ledger. ", "technologies": ["Go", " GO", "MySQL"]}]}` + "\n```",
+			wantSkills:   []string{"Go", "SQL"},
+			wantProjects: []ProfileProject{{Name: "Ledger", Summary: "A payments ledger.", Technologies: []string{"Go", "MySQL"}}},
+		},
+		{
+			name:         "empty",
+			reply:        `{"skills": [], "projects": []}`,
+			wantSkills:   []string{},
+			wantProjects: []ProfileProject{},
+		},
+		{
+			name:         "capped",
+			reply:        `{"skills": [` + strings.Join(manySkills, ", ") + `], "projects": [` + strings.Join(manyProjects, ", ") + `]}`,
+			wantSkills:   keptSkills,
+			wantProjects: keptProjects,
+		},
+		{
+			name:    "project without a name",
+			reply:   `{"skills": ["Go"], "projects": [{"name": " ", "summary": "x", "technologies": []}]}`,
+			wantErr: "project 1 has no name",
+		},
+		{
+			name:    "unknown field",
+			reply:   `{"skills": ["Go"], "projects": [], "seniority": "senior"}`,
+			wantErr: "does not match schema",
+		},
+		{
+			name:    "trailing data",
+			reply:   `{"skills": [], "projects": []} {}`,
+			wantErr: "unexpected data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := parseProfile(tt.reply)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(profile.Skills, tt.wantSkills) {
+				t.Errorf("Skills = %q, want %q", profile.Skills, tt.wantSkills)
+			}
+			if !reflect.DeepEqual(profile.Projects, tt.wantProjects) {
+				t.Errorf("Projects = %+v, want %+v", profile.Projects, tt.wantProjects)
+			}
+		})
+	}
+}
+
+func TestFormatProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *Profile
+		want    string
+	}{
+		{name: "nil"},
+		{name: "empty", profile: &Profile{Skills: []string{}, Projects: []ProfileProject{}}},
+		{
+			name:    "skills only",
+			profile: &Profile{Skills: []string{"Go", "SQL"}},
+			want:    "Skills: Go, SQL",
+		},
+		{
+			name: "projects",
+			profile: &Profile{Skills: []string{"Go"}, Projects: []ProfileProject{
+				{Name: "Ledger", Summary: "A payments ledger.", Technologies: []string{"Go", "MySQL"}},
+				{Name: "Blog"},
+			}},
+			want: "Skills: Go\nProjects:\n- Ledger (Go, MySQL): A payments ledger.\n- Blog",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatProfile(tt.profile); got != tt.want {
+				t.Errorf("formatProfile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
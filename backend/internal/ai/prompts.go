@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Prompt names. Each is loaded from "<name>.tmpl".
+const (
+	PromptQuestions     = "questions"
+	PromptEvaluation    = "evaluation"
+	PromptFinalFeedback = "final_feedback"
+	PromptFollowUp      = "follow_up"
+	PromptHint          = "hint"
+	PromptModelAnswer   = "model_answer"
+	PromptRepair        = "repair"
+	PromptProfile       = "profile"
+)
+
+var promptNames = []string{PromptQuestions, PromptEvaluation, PromptFinalFeedback, PromptFollowUp, PromptHint, PromptModelAnswer, PromptRepair, PromptProfile}
+
+//go:embed prompts/*.tmpl
+var defaultPrompts embed.FS
+
+// versionPattern matches the header comment every template should start
+// with, e.g. {{/* version: evaluation-v2 */ -}}
+var versionPattern = regexp.MustCompile(`^\{\{-?\s*/\*\s*version:\s*(\S+)\s*\*/`)
+
+// untrustedPartial is the name of the template shared by every prompt
+// that explains the candidate delimiter to the model. It is loaded from
+// "<name>.tmpl" like the prompts.
+const untrustedPartial = "untrusted"
+
+// promptFuncs are available to every template. Render binds candidate and
+// candidateTag to a delimiter tag of its own.
+var promptFuncs = template.FuncMap{
+	// inc turns a zero-based range index into a one-based number
+	"inc": func(i int) int { return i + 1 },
+	// candidate delimits text written by the candidate
+	"candidate": func(text string) string { return delimitCandidate(candidateTag, text) },
+	// candidateTag is the name of the tag candidate delimits text with
+	"candidateTag": func() string { return candidateTag },
+	// score formats an optional score out of 10
+	"score": func(score *float64) string {
+		if score == nil {
+			return "not scored"
+		}
+		return fmt.Sprintf("%.1f/10", *score)
+	},
+}
+
+// Prompt is a parsed prompt template and the version it declares.
+type Prompt struct {
+	Name    string
+	Version string
+	tmpl    *template.Template
+}
+
+// Render executes the template with data, delimiting candidate text with
+// a tag no earlier prompt used.
+func (p *Prompt) Render(data interface{}) (string, error) {
+	tag, err := newCandidateTag()
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", p.Name, err)
+	}
+	tmpl, err := p.tmpl.Clone()
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", p.Name, err)
+	}
+	tmpl.Funcs(template.FuncMap{
+		"candidate":    func(text string) string { return delimitCandidate(tag, text) },
+		"candidateTag": func() string { return tag },
+	})
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", p.Name, err)
+	}
+	return buf.String(), nil
+}
+
+// Prompts is the set of templates used by LLMProvider.
+type Prompts struct {
+	byName map[string]*Prompt
+}
+
+// LoadPrompts reads the prompt templates from dir. Templates missing from
+// dir, or all of them when dir is empty, fall back to the built-in ones.
+func LoadPrompts(dir string) (*Prompts, error) {
+	prompts := &Prompts{byName: make(map[string]*Prompt)}
+
+	partial, err := readPrompt(dir, untrustedPartial)
+	if err != nil {
+		return nil, err
+	}
+	// The partial is included mid-template, where the file's final newline
+	// would add a blank line
+	partial = strings.TrimRight(partial, "\n")
+	for _, name := range promptNames {
+		text, err := readPrompt(dir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		prompt, err := parsePrompt(name, text, partial)
+		if err != nil {
+			return nil, err
+		}
+		prompts.byName[name] = prompt
+	}
+
+	return prompts, nil
+}
+
+// readPrompt reads "<name>.tmpl" from dir, or the built-in one if dir is
+// empty or has no such file.
+func readPrompt(dir, name string) (string, error) {
+	file := name + ".tmpl"
+
+	var text []byte
+	var err error
+	if dir != "" {
+		text, err = os.ReadFile(filepath.Join(dir, file))
+	}
+	if dir == "" || errors.Is(err, fs.ErrNotExist) {
+		text, err = defaultPrompts.ReadFile("prompts/" + file)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s prompt: %w", name, err)
+	}
+	return string(text), nil
+}
+
+// parsePrompt parses a prompt template together with the untrusted
+// partial, which it can include as {{template "untrusted" "subject"}}.
+func parsePrompt(name, text, partial string) (*Prompt, error) {
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s prompt: %w", name, err)
+	}
+	if _, err := tmpl.New(untrustedPartial).Parse(partial); err != nil {
+		return nil, fmt.Errorf("failed to parse %s prompt: %w", untrustedPartial, err)
+	}
+
+	// Templates without a version header are identified by their content
+	version := ""
+	if m := versionPattern.FindStringSubmatch(text); m != nil {
+		version = m[1]
+	} else {
+		sum := sha256.Sum256([]byte(text))
+		version = name + "-" + hex.EncodeToString(sum[:])[:12]
+	}
+
+	return &Prompt{Name: name, Version: version, tmpl: tmpl}, nil
+}
+
+// Get returns the named prompt. It panics on unknown names, which are
+// programming errors since LoadPrompts loads every known prompt.
+func (p *Prompts) Get(name string) *Prompt {
+	prompt, ok := p.byName[name]
+	if !ok {
+		panic("ai: unknown prompt " + name)
+	}
+	return prompt
+}
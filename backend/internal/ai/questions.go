@@ -0,0 +1,286 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ai-interviewer/backend/internal/dataset"
+)
+
+// GeneratedQuestion is a question together with the model's own
+// classification of it.
+type GeneratedQuestion struct {
+	Text       string   `json:"question"`
+	Type       string   `json:"type"`
+	Topics     []string `json:"topics"`
+	Difficulty string   `json:"difficulty"`
+
+	// ReferenceAnswer and KeyPoints are hidden from the candidate and used
+	// to ground the evaluation of their answer.
+	ReferenceAnswer string   `json:"reference_answer"`
+	KeyPoints       []string `json:"key_points"`
+
+	// TestCases are run against the candidate's code for coding questions
+	TestCases []TestCase `json:"test_cases,omitempty"`
+
+	// Dataset is what the candidate's query runs against for SQL questions
+	Dataset *SQLDataset `json:"dataset,omitempty"`
+
+	// Options and the answer key of quiz questions, which are graded
+	// without the model
+	Options         []string `json:"options,omitempty"`
+	CorrectOptions  []int    `json:"correct_options,omitempty"` // zero-based indexes into Options
+	AcceptedAnswers []string `json:"accepted_answers,omitempty"`
+	AnswerPattern   string   `json:"answer_pattern,omitempty"` // regular expression a short answer must match in full
+
+	// PromptVersion identifies the template that produced the question
+	PromptVersion string `json:"-"`
+}
+
+// TestCase is one stdin/stdout check of a solution to a coding question.
+type TestCase struct {
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+}
+
+// SQLDataset is the schema and seed data of a SQL question, with a
+// solution query whose result candidate queries are compared to.
+type SQLDataset struct {
+	Schema   string `json:"schema"`
+	Seed     string `json:"seed"`
+	Solution string `json:"solution"`
+	Ordered  bool   `json:"ordered"` // whether the order of the result rows matters
+
+	// Expected is the result of Solution, filled in by validation
+	Expected *dataset.ResultSet `json:"-"`
+}
+
+// datasetCheckTimeout bounds loading a generated dataset and running its
+// solution.
+const datasetCheckTimeout = 10 * time.Second
+
+// QuestionRequest describes the questions to generate.
+type QuestionRequest struct {
+	Position   string
+	Difficulty string
+	Count      int
+	Asked      []string // questions already asked in this interview, not to be repeated
+	Profile    *Profile // the candidate's resume profile, if any, to tailor questions to
+}
+
+// QuestionTypes lists the question types the model may assign.
+var QuestionTypes = []string{"technical", "behavioral", "coding", "system_design", "sql", "single_choice", "multi_choice", "short_answer"}
+
+// QuizTypes are the question types graded against an answer key rather
+// than by the model.
+var QuizTypes = []string{"single_choice", "multi_choice", "short_answer"}
+
+// maxOptions caps the options of a choice question.
+const maxOptions = 6
+
+var difficulties = []string{"easy", "medium", "hard"}
+
+const questionsSchema = `[
+  {
+    "question": string, the question text,
+    "type": one of "technical", "behavioral", "coding", "system_design", "sql",
+      "single_choice", "multi_choice", "short_answer",
+    "topics": array of 1-3 short topic tags (e.g. "concurrency", "teamwork"),
+    "difficulty": one of "easy", "medium", "hard" (your own estimate),
+    "reference_answer": string, a concise model answer,
+    "key_points": array of 3-5 short points a strong answer must cover,
+    "test_cases": for "coding" questions an array of 3-5 objects
+      {"input": string written to the program's stdin, "expected_output": string the program must print},
+      for other types an empty array,
+    "dataset": for "sql" questions an object
+      {"schema": string of SQLite CREATE TABLE statements,
+       "seed": string of INSERT statements filling the tables,
+       "solution": string, a SELECT query answering the question,
+       "ordered": boolean, true if the question asks for the rows in a specific order},
+      omitted for other types,
+    "options": for "single_choice" and "multi_choice" questions an array of
+      2-6 answer options, omitted for other types,
+    "correct_options": for "single_choice" (exactly one) and "multi_choice"
+      (one or more) questions the zero-based indexes of the correct options,
+      omitted for other types,
+    "accepted_answers": for "short_answer" questions an array of correct
+      answers, compared ignoring case and extra spaces, omitted for other types,
+    "answer_pattern": for "short_answer" questions, optionally a regular
+      expression (RE2 syntax) that any correct answer matches in full,
+      omitted otherwise
+  }
+]`
+
+// parseGeneratedQuestions strictly decodes and validates a model reply
+// against questionsSchema.
+func parseGeneratedQuestions(ctx context.Context, response string) ([]GeneratedQuestion, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(extractJSON(response))))
+	dec.DisallowUnknownFields()
+
+	var questions []GeneratedQuestion
+	if err := dec.Decode(&questions); err != nil {
+		return nil, fmt.Errorf("response does not match schema: %w", err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON array")
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("no questions generated")
+	}
+
+	for i := range questions {
+		if err := validateGeneratedQuestion(&questions[i]); err != nil {
+			return nil, fmt.Errorf("question %d %w", i+1, err)
+		}
+		if err := validateTypeFields(ctx, &questions[i]); err != nil {
+			return nil, fmt.Errorf("question %d %w", i+1, err)
+		}
+	}
+
+	return questions, nil
+}
+
+// validateGeneratedQuestion normalizes q in place and checks that every
+// required field is present.
+func validateGeneratedQuestion(q *GeneratedQuestion) error {
+	q.Text = strings.TrimSpace(q.Text)
+	q.Type = strings.ToLower(strings.TrimSpace(q.Type))
+	q.Difficulty = strings.ToLower(strings.TrimSpace(q.Difficulty))
+	if q.Text == "" {
+		return fmt.Errorf("has no text")
+	}
+	if !slices.Contains(QuestionTypes, q.Type) {
+		return fmt.Errorf("has invalid type %q", q.Type)
+	}
+	if !slices.Contains(difficulties, q.Difficulty) {
+		return fmt.Errorf("has invalid difficulty %q", q.Difficulty)
+	}
+	if q.Topics == nil {
+		q.Topics = []string{}
+	}
+	q.ReferenceAnswer = strings.TrimSpace(q.ReferenceAnswer)
+	if q.ReferenceAnswer == "" {
+		return fmt.Errorf("has no reference answer")
+	}
+	if len(q.KeyPoints) == 0 {
+		return fmt.Errorf("has no key points")
+	}
+	return nil
+}
+
+// validateTypeFields checks the fields specific to q's type and drops
+// those that belong to other types.
+func validateTypeFields(ctx context.Context, q *GeneratedQuestion) error {
+	if err := validateTestCases(q); err != nil {
+		return err
+	}
+	if err := validateDataset(ctx, q); err != nil {
+		return err
+	}
+	return validateAnswerKey(q)
+}
+
+// validateTestCases checks that coding questions come with test cases and
+// drops any given for other question types.
+func validateTestCases(q *GeneratedQuestion) error {
+	if q.Type != "coding" {
+		q.TestCases = nil
+		return nil
+	}
+	if len(q.TestCases) == 0 {
+		return fmt.Errorf("is a coding question without test cases")
+	}
+	for i, tc := range q.TestCases {
+		if strings.TrimSpace(tc.ExpectedOutput) == "" {
+			return fmt.Errorf("test case %d has no expected output", i+1)
+		}
+	}
+	return nil
+}
+
+// validateDataset checks that SQL questions come with a dataset whose
+// solution runs and returns rows, recording its result, and drops any
+// dataset given for other question types.
+func validateDataset(ctx context.Context, q *GeneratedQuestion) error {
+	if q.Type != "sql" {
+		q.Dataset = nil
+		return nil
+	}
+	d := q.Dataset
+	if d == nil {
+		return fmt.Errorf("is a sql question without a dataset")
+	}
+	if strings.TrimSpace(d.Schema) == "" || strings.TrimSpace(d.Seed) == "" || strings.TrimSpace(d.Solution) == "" {
+		return fmt.Errorf("dataset needs a schema, seed data and a solution")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, datasetCheckTimeout)
+	defer cancel()
+	expected, err := dataset.Query(ctx, dataset.Dataset{Schema: d.Schema, Seed: d.Seed}, d.Solution)
+	if err != nil {
+		return fmt.Errorf("dataset solution fails: %w", err)
+	}
+	if len(expected.Rows) == 0 {
+		return fmt.Errorf("dataset solution returns no rows")
+	}
+	d.Expected = expected
+	return nil
+}
+
+// validateAnswerKey checks the options and answer key of quiz questions
+// and drops any given for other question types.
+func validateAnswerKey(q *GeneratedQuestion) error {
+	switch q.Type {
+	case "single_choice", "multi_choice":
+		q.AcceptedAnswers, q.AnswerPattern = nil, ""
+		if len(q.Options) < 2 || len(q.Options) > maxOptions {
+			return fmt.Errorf("needs 2-%d options, has %d", maxOptions, len(q.Options))
+		}
+		for i := range q.Options {
+			q.Options[i] = strings.TrimSpace(q.Options[i])
+			if q.Options[i] == "" {
+				return fmt.Errorf("option %d is empty", i)
+			}
+		}
+		if len(q.CorrectOptions) == 0 {
+			return fmt.Errorf("has no correct option")
+		}
+		if q.Type == "single_choice" && len(q.CorrectOptions) != 1 {
+			return fmt.Errorf("is a single_choice question with %d correct options", len(q.CorrectOptions))
+		}
+		seen := make(map[int]bool)
+		for _, i := range q.CorrectOptions {
+			if i < 0 || i >= len(q.Options) || seen[i] {
+				return fmt.Errorf("has invalid correct option %d", i)
+			}
+			seen[i] = true
+		}
+	case "short_answer":
+		q.Options, q.CorrectOptions = nil, nil
+		var accepted []string
+		for _, a := range q.AcceptedAnswers {
+			if a = strings.TrimSpace(a); a != "" {
+				accepted = append(accepted, a)
+			}
+		}
+		q.AcceptedAnswers = accepted
+		q.AnswerPattern = strings.TrimSpace(q.AnswerPattern)
+		if len(q.AcceptedAnswers) == 0 && q.AnswerPattern == "" {
+			return fmt.Errorf("is a short_answer question without accepted answers")
+		}
+		if q.AnswerPattern != "" {
+			if _, err := regexp.Compile(q.AnswerPattern); err != nil {
+				return fmt.Errorf("has an invalid answer pattern: %w", err)
+			}
+		}
+	default:
+		q.Options, q.CorrectOptions, q.AcceptedAnswers, q.AnswerPattern = nil, nil, nil, ""
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorKind classifies a provider failure by how callers should react.
+type ErrorKind int
+
+const (
+	// ErrorPermanent failures (bad request, auth, ...) will not succeed on retry.
+	ErrorPermanent ErrorKind = iota
+	// ErrorRetryable failures (5xx, timeouts, dropped connections) are transient.
+	ErrorRetryable
+	// ErrorQuota failures mean the provider is rate limiting or out of quota.
+	ErrorQuota
+)
+
+var (
+	// ErrUnavailable matches provider errors that were still transient after
+	// all retries.
+	ErrUnavailable = errors.New("AI provider unavailable")
+	// ErrQuotaExceeded matches provider errors caused by rate limits or quota.
+	ErrQuotaExceeded = errors.New("AI provider quota exceeded")
+)
+
+// ProviderError is a classified error from a model backend. Use errors.Is
+// with ErrUnavailable or ErrQuotaExceeded to test its kind.
+type ProviderError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *ProviderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProviderError) Is(target error) bool {
+	switch target {
+	case ErrUnavailable:
+		return e.Kind == ErrorRetryable
+	case ErrQuotaExceeded:
+		return e.Kind == ErrorQuota
+	}
+	return false
+}
+
+// statusError builds a ProviderError for a non-2xx HTTP reply.
+func statusError(code int, format string, args ...interface{}) error {
+	return &ProviderError{Kind: classifyStatus(code), Err: fmt.Errorf(format, args...)}
+}
+
+func classifyStatus(code int) ErrorKind {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return ErrorQuota
+	case code == http.StatusRequestTimeout || code >= 500:
+		return ErrorRetryable
+	default:
+		return ErrorPermanent
+	}
+}
+
+// classify decides how to treat err from a Completer.
+func classify(err error) ErrorKind {
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.Kind
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorPermanent
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorRetryable
+	}
+
+	var httpErr interface{ HTTPCode() int }
+	if errors.As(err, &httpErr) && httpErr.HTTPCode() > 0 {
+		return classifyStatus(httpErr.HTTPCode())
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		switch s.Code() {
+		case codes.ResourceExhausted:
+			return ErrorQuota
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Aborted:
+			return ErrorRetryable
+		default:
+			return ErrorPermanent
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorRetryable
+	}
+
+	return ErrorPermanent
+}
+
+// RetryingCompleter retries retryable failures of the wrapped Completer with
+// jittered exponential backoff. Quota and permanent errors are returned
+// immediately. Every error it returns is a *ProviderError.
+type RetryingCompleter struct {
+	next        Completer
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+func NewRetryingCompleter(next Completer, maxAttempts int, baseDelay time.Duration) *RetryingCompleter {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &RetryingCompleter{
+		next:        next,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		maxDelay:    30 * time.Second,
+	}
+}
+
+func (c *RetryingCompleter) Complete(ctx context.Context, prompt string) (string, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var response string
+		response, err = c.next.Complete(ctx, prompt)
+		if err == nil {
+			return response, nil
+		}
+
+		kind := classify(err)
+		if kind != ErrorRetryable || attempt >= c.maxAttempts || ctx.Err() != nil {
+			return "", &ProviderError{Kind: kind, Err: err}
+		}
+
+		delay := c.backoff(attempt)
+		log.Printf("AI call failed (attempt %d/%d), retrying in %s: %v", attempt, c.maxAttempts, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", &ProviderError{Kind: kind, Err: err}
+		}
+	}
+}
+
+// backoff returns a random delay in [0, min(maxDelay, baseDelay*2^(attempt-1))].
+func (c *RetryingCompleter) backoff(attempt int) time.Duration {
+	limit := c.baseDelay << (attempt - 1)
+	if limit > c.maxDelay || limit <= 0 {
+		limit = c.maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// httpCodeError is an error carrying an HTTP status, like the Gemini
+// client's.
+type httpCodeError int
+
+func (e httpCodeError) Error() string { return fmt.Sprintf("HTTP %d", int(e)) }
+func (e httpCodeError) HTTPCode() int { return int(e) }
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"provider error", &ProviderError{Kind: ErrorQuota, Err: errors.New("slow down")}, ErrorQuota},
+		{"wrapped provider error", fmt.Errorf("call: %w", &ProviderError{Kind: ErrorRetryable, Err: errors.New("502")}), ErrorRetryable},
+		{"too many requests", statusError(http.StatusTooManyRequests, "429"), ErrorQuota},
+		{"request timeout", statusError(http.StatusRequestTimeout, "408"), ErrorRetryable},
+		{"server error", statusError(http.StatusBadGateway, "502"), ErrorRetryable},
+		{"bad request", statusError(http.StatusBadRequest, "400"), ErrorPermanent},
+		{"unauthorized", statusError(http.StatusUnauthorized, "401"), ErrorPermanent},
+		{"cancelled", context.Canceled, ErrorPermanent},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), ErrorRetryable},
+		{"HTTP code 503", httpCodeError(http.StatusServiceUnavailable), ErrorRetryable},
+		{"HTTP code 429", httpCodeError(http.StatusTooManyRequests), ErrorQuota},
+		{"HTTP code 403", httpCodeError(http.StatusForbidden), ErrorPermanent},
+		{"gRPC resource exhausted", status.Error(codes.ResourceExhausted, "quota"), ErrorQuota},
+		{"gRPC unavailable", status.Error(codes.Unavailable, "down"), ErrorRetryable},
+		{"gRPC internal", status.Error(codes.Internal, "oops"), ErrorRetryable},
+		{"gRPC invalid argument", status.Error(codes.InvalidArgument, "bad"), ErrorPermanent},
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrorRetryable},
+		{"other", errors.New("unexpected"), ErrorPermanent},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.err); got != tt.want {
+			t.Errorf("%s: classify(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+// scriptedCompleter fails with errs in turn, then succeeds.
+type scriptedCompleter struct {
+	errs  []error
+	calls int
+}
+
+func (c *scriptedCompleter) Complete(ctx context.Context, prompt string) (string, error) {
+	c.calls++
+	if c.calls <= len(c.errs) {
+		return "", c.errs[c.calls-1]
+	}
+	return "ok", nil
+}
+
+func TestRetryingCompleter(t *testing.T) {
+	transient := statusError(http.StatusServiceUnavailable, "503")
+	quota := statusError(http.StatusTooManyRequests, "429")
+	permanent := statusError(http.StatusBadRequest, "400")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error // nil for success, else the sentinel the error must match
+		wantKind  ErrorKind
+	}{
+		{"success", nil, 1, nil, 0},
+		{"transient then success", []error{transient, transient}, 3, nil, 0},
+		{"transient until attempts run out", []error{transient, transient, transient, transient}, 3, ErrUnavailable, ErrorRetryable},
+		{"quota is not retried", []error{quota}, 1, ErrQuotaExceeded, ErrorQuota},
+		{"permanent is not retried", []error{permanent}, 1, permanent, ErrorPermanent},
+		{"unclassified errors are permanent", []error{errors.New("boom")}, 1, nil, ErrorPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &scriptedCompleter{errs: tt.errs}
+			c := NewRetryingCompleter(next, 3, time.Millisecond)
+
+			response, err := c.Complete(context.Background(), "prompt")
+			if next.calls != tt.wantCalls {
+				t.Errorf("made %d calls, want %d", next.calls, tt.wantCalls)
+			}
+			if tt.wantCalls > len(tt.errs) {
+				if err != nil || response != "ok" {
+					t.Fatalf("Complete() = %q, %v, want ok", response, err)
+				}
+				return
+			}
+
+			var pe *ProviderError
+			if !errors.As(err, &pe) {
+				t.Fatalf("err = %v, want a *ProviderError", err)
+			}
+			if pe.Kind != tt.wantKind {
+				t.Errorf("Kind = %v, want %v", pe.Kind, tt.wantKind)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryingCompleterStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	next := &scriptedCompleter{errs: []error{statusError(http.StatusServiceUnavailable, "503")}}
+	// The backoff would outlast the test, so only cancellation ends it
+	c := NewRetryingCompleter(next, 5, time.Hour)
+	c.maxDelay = time.Hour
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Complete(ctx, "prompt")
+		done <- err
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrUnavailable) {
+			t.Errorf("err = %v, want one matching ErrUnavailable", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Complete did not return after its context was cancelled")
+	}
+	if next.calls != 1 {
+		t.Errorf("made %d calls after cancellation, want 1", next.calls)
+	}
+
+	// A context cancelled before the call is not retried either
+	next = &scriptedCompleter{errs: []error{statusError(http.StatusServiceUnavailable, "503")}}
+	if _, err := NewRetryingCompleter(next, 5, time.Millisecond).Complete(ctx, "prompt"); err == nil || next.calls != 1 {
+		t.Errorf("with a cancelled context: err = %v after %d calls, want an error after 1", err, next.calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := NewRetryingCompleter(nil, 5, 100*time.Millisecond)
+	c.maxDelay = time.Second
+	for attempt, limit := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second, 70: time.Second} {
+		for i := 0; i < 50; i++ {
+			if d := c.backoff(attempt); d < 0 || d > limit {
+				t.Fatalf("backoff(%d) = %s, want within [0, %s]", attempt, d, limit)
+			}
+		}
+	}
+}
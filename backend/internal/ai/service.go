@@ -3,182 +3,91 @@ package ai
 import (
 	"context"
 	"fmt"
-	"strings"
+	"path/filepath"
+	"slices"
 
-	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
+	"github.com/ai-interviewer/backend/internal/config"
 )
 
-type AIService struct {
-	client *genai.Client
-	model  *genai.GenerativeModel
+// Provider is the set of operations the interview flow needs from an LLM.
+// Each backend (Gemini, OpenAI-compatible servers, Ollama, the offline
+// fake, ...) implements it so handlers never depend on a vendor SDK.
+type Provider interface {
+	GenerateQuestions(ctx context.Context, req QuestionRequest) ([]GeneratedQuestion, error)
+	EvaluateAnswer(ctx context.Context, req EvaluationRequest) (*Evaluation, error)
+	GenerateFinalFeedback(ctx context.Context, req FinalFeedbackRequest) (*FinalFeedback, error)
+	// GenerateFollowUp returns a probing follow-up question for a shallow or
+	// ambiguous answer, or nil if the answer needs none.
+	GenerateFollowUp(ctx context.Context, req FollowUpRequest) (*GeneratedQuestion, error)
+	GenerateHint(ctx context.Context, req HintRequest) (*Hint, error)
+	GenerateModelAnswer(ctx context.Context, req ModelAnswerRequest) (*ModelAnswer, error)
+	// ExtractProfile lists the skills and projects described in a resume.
+	ExtractProfile(ctx context.Context, req ProfileRequest) (*Profile, error)
 }
 
-func NewAIService(apiKey string) (*AIService, error) {
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+// NewProvider builds the provider selected by cfg.AIProvider, wrapped in a
+// circuit breaker. When answer sampling or extra evaluation providers are
+// configured, evaluations go through an Ensemble.
+func NewProvider(cfg *config.Config) (Provider, error) {
+	provider, err := newProvider(cfg, cfg.AIProvider)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Gemini: %w", err)
+		return nil, err
 	}
 
-	// Use gemini-2.5-flash - stable version released June 2025
-	model := client.GenerativeModel("gemini-2.5-flash")
-
-	return &AIService{
-		client: client,
-		model:  model,
-	}, nil
-}
-
-func (s *AIService) GenerateQuestions(ctx context.Context, position, difficulty string, count int) ([]string, error) {
-	prompt := fmt.Sprintf(`You are an expert technical interviewer. Generate %d interview questions for a %s position with %s difficulty level.
-
-Mix the questions between:
-- Technical knowledge questions
-- Behavioral questions
-- Problem-solving scenarios
-
-Return ONLY the questions, one per line, numbered 1. 2. 3. etc.
-Do not include any other text or explanations.
-
-Position: %s
-Difficulty: %s
-Number of questions: %d`, count, position, difficulty, position, difficulty, count)
-
-	resp, err := s.model.GenerateContent(ctx, genai.Text(prompt))
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate questions: %w", err)
-	}
-
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("no response from AI")
-	}
-
-	response := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
-	questions := parseQuestions(response)
-	if len(questions) == 0 {
-		return nil, fmt.Errorf("no questions generated")
+	if cfg.EvalSamples > 1 || len(cfg.EvalProviders) > 0 {
+		members := []Provider{provider}
+		for _, name := range cfg.EvalProviders {
+			member, err := newProvider(cfg, name)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, member)
+		}
+		provider = NewEnsemble(members, cfg.EvalSamples, cfg.ReviewSpread)
 	}
 
-	return questions, nil
+	return NewCircuitBreaker(provider, cfg.AIBreakerThreshold, cfg.AIBreakerCooldown), nil
 }
 
-func (s *AIService) EvaluateAnswer(ctx context.Context, question, answer string) (string, float64, error) {
-	prompt := fmt.Sprintf(`You are an expert interviewer evaluating a candidate's response.
-
-Question: %s
-
-Candidate's Answer: %s
+// llmProviders are the provider names backed by a language model.
+var llmProviders = []string{"gemini", "openai", "ollama"}
 
-Please provide:
-1. A score from 0-10 (where 10 is excellent)
-2. Constructive feedback (2-3 sentences)
-
-Format your response EXACTLY as:
-Score: X
-Feedback: Your feedback here
-
-Be constructive and specific in your feedback.`, question, answer)
-
-	resp, err := s.model.GenerateContent(ctx, genai.Text(prompt))
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to evaluate answer: %w", err)
+func newProvider(cfg *config.Config, name string) (Provider, error) {
+	if name == "fake" {
+		return NewFakeProvider(cfg.FakeFixtures)
 	}
 
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "Unable to evaluate answer at this time.", 5.0, nil
+	if !slices.Contains(llmProviders, name) {
+		return nil, fmt.Errorf("unknown AI provider: %q", name)
 	}
 
-	response := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
-	score, feedback := parseEvaluation(response)
-	return feedback, score, nil
-}
-
-func (s *AIService) GenerateFinalFeedback(ctx context.Context, position string, averageScore float64, totalQuestions int) (string, error) {
-	prompt := fmt.Sprintf(`You are an expert interviewer providing final feedback for a candidate.
-
-Position: %s
-Average Score: %.2f/10
-Total Questions: %d
-
-Provide a comprehensive summary (3-4 sentences) that includes:
-1. Overall performance assessment
-2. Key strengths observed
-3. Areas for improvement
-4. Recommendation (hire/consider/not recommended)
-
-Be professional, constructive, and specific.`, position, averageScore, totalQuestions)
-
-	resp, err := s.model.GenerateContent(ctx, genai.Text(prompt))
+	prompts, err := LoadPrompts(cfg.PromptsDir)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate final feedback: %w", err)
+		return nil, err
 	}
 
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "Thank you for completing the interview.", nil
-	}
-
-	response := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
-	return strings.TrimSpace(response), nil
-}
-
-func parseQuestions(response string) []string {
-	lines := strings.Split(response, "\n")
-	var questions []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Remove numbering (1., 2., etc.)
-		parts := strings.SplitN(line, ".", 2)
-		if len(parts) == 2 {
-			question := strings.TrimSpace(parts[1])
-			if question != "" {
-				questions = append(questions, question)
-			}
-		} else if line != "" {
-			questions = append(questions, line)
+	var client Completer
+	switch {
+	case CassetteMode(cfg.CassetteMode) == CassetteReplay:
+		// Replay never reaches the real model
+	case name == "gemini":
+		client, err = NewGeminiClient(cfg.GeminiAPIKey, cfg.GeminiModel)
+		if err != nil {
+			return nil, err
 		}
+	case name == "openai":
+		client = NewOpenAIClient(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModel)
+	case name == "ollama":
+		client = NewOllamaClient(cfg.OllamaBaseURL, cfg.OllamaModel)
 	}
 
-	return questions
-}
-
-func parseEvaluation(response string) (float64, string) {
-	lines := strings.Split(response, "\n")
-	var score float64 = 5.0 // default score
-	var feedback string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if strings.HasPrefix(strings.ToLower(line), "score:") {
-			scoreStr := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(line), "score:"))
-			// Parse score (handle formats like "8", "8.5", "8/10")
-			var parsedScore float64
-			if strings.Contains(scoreStr, "/") {
-				fmt.Sscanf(scoreStr, "%f/", &parsedScore)
-			} else {
-				fmt.Sscanf(scoreStr, "%f", &parsedScore)
-			}
-			if parsedScore >= 0 && parsedScore <= 10 {
-				score = parsedScore
-			}
-		} else if strings.HasPrefix(strings.ToLower(line), "feedback:") {
-			feedback = strings.TrimSpace(strings.TrimPrefix(line, "Feedback:"))
-			feedback = strings.TrimSpace(strings.TrimPrefix(feedback, "feedback:"))
-		} else if feedback != "" {
-			// Append additional feedback lines
-			feedback += " " + line
+	if cfg.CassetteMode != "" {
+		// Each provider records to its own directory
+		client, err = NewCassetteCompleter(client, filepath.Join(cfg.CassetteDir, name), CassetteMode(cfg.CassetteMode))
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	if feedback == "" {
-		feedback = "Good effort. Keep practicing to improve your skills."
-	}
-
-	return score, strings.TrimSpace(feedback)
+	return NewLLMProvider(NewRetryingCompleter(client, cfg.AIMaxAttempts, cfg.AIRetryBaseDelay), prompts), nil
 }
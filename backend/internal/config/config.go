@@ -3,20 +3,64 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	DBHost       string
-	DBPort       string
-	DBUser       string
-	DBPassword   string
-	DBName       string
-	GeminiAPIKey string
-	Port         string
+	DBHost         string
+	DBPort         string
+	DBUser         string
+	DBPassword     string
+	DBName         string
+	AIProvider     string // gemini, openai, ollama, fake
+	GeminiAPIKey   string
+	GeminiModel    string
+	OpenAIBaseURL  string
+	OpenAIAPIKey   string
+	OpenAIModel    string
+	OllamaBaseURL  string
+	OllamaModel    string
+	FakeFixtures   string
+	PromptsDir     string
+	CassetteMode   string // "", record, replay
+	CassetteDir    string
+	Port           string
 	AllowedOrigins []string
+
+	// Retry policy for transient AI provider errors
+	AIMaxAttempts    int
+	AIRetryBaseDelay time.Duration
+
+	// Circuit breaker around the AI provider
+	AIBreakerThreshold int
+	AIBreakerCooldown  time.Duration
+	PendingEvalPeriod  time.Duration
+
+	// Ensemble grading: samples per provider, extra providers to sample and
+	// the score spread above which an answer is flagged for human review
+	EvalSamples   int
+	EvalProviders []string
+	ReviewSpread  float64
+
+	// Default maximum depth of follow-up questions per interview; 0
+	// disables follow-ups
+	FollowUpMaxDepth int
+
+	// Points deducted from an answer's score for every hint used
+	HintPenalty float64
+
+	// Limits for running candidate code against test cases: wall-clock
+	// time per test and memory per process
+	SandboxTimeout  time.Duration
+	SandboxMemoryMB int
+
+	// Base URL of the runner service candidate code runs in; empty runs it
+	// in this process
+	SandboxURL string
 }
 
 func Load() (*Config, error) {
@@ -24,36 +68,148 @@ func Load() (*Config, error) {
 	godotenv.Load()
 
 	config := &Config{
-		DBHost:       getEnv("DB_HOST", "localhost"),
-		DBPort:       getEnv("DB_PORT", "3306"),
-		DBUser:       getEnv("DB_USER", "interviewer"),
-		DBPassword:   getEnv("DB_PASSWORD", "interviewerpass"),
-		DBName:       getEnv("DB_NAME", "ai_interviewer"),
-		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
-		Port:         getEnv("PORT", "8080"),
+		DBHost:        getEnv("DB_HOST", "localhost"),
+		DBPort:        getEnv("DB_PORT", "3306"),
+		DBUser:        getEnv("DB_USER", "interviewer"),
+		DBPassword:    getEnv("DB_PASSWORD", "interviewerpass"),
+		DBName:        getEnv("DB_NAME", "ai_interviewer"),
+		AIProvider:    strings.ToLower(getEnv("AI_PROVIDER", "gemini")),
+		GeminiAPIKey:  getEnv("GEMINI_API_KEY", ""),
+		GeminiModel:   getEnv("GEMINI_MODEL", "gemini-2.5-flash"),
+		OpenAIBaseURL: getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIAPIKey:  getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:   getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+		OllamaBaseURL: getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
+		OllamaModel:   getEnv("OLLAMA_MODEL", "llama3.1"),
+		FakeFixtures:  getEnv("AI_FAKE_FIXTURES", ""),
+		PromptsDir:    getEnv("AI_PROMPTS_DIR", ""),
+		CassetteMode:  strings.ToLower(getEnv("AI_CASSETTE_MODE", "")),
+		CassetteDir:   getEnv("AI_CASSETTE_DIR", "cassettes"),
+		Port:          getEnv("PORT", "8080"),
+		SandboxURL:    getEnv("SANDBOX_URL", ""),
 	}
 
 	// Parse allowed origins (comma-separated) into a slice
-	allowed := getEnv("ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:5173")
-	var origins []string
-	for _, s := range strings.Split(allowed, ",") {
-		s = strings.TrimSpace(s)
-		if s != "" {
-			origins = append(origins, s)
-		}
+	config.AllowedOrigins = splitList(getEnv("ALLOWED_ORIGINS", "http://localhost:3000,http://localhost:5173"))
+
+	var err error
+	if config.AIMaxAttempts, err = getEnvInt("AI_MAX_ATTEMPTS", 3); err != nil {
+		return nil, err
+	}
+	if config.AIRetryBaseDelay, err = getEnvDuration("AI_RETRY_BASE_DELAY", 500*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if config.AIBreakerThreshold, err = getEnvInt("AI_BREAKER_THRESHOLD", 5); err != nil {
+		return nil, err
 	}
-	config.AllowedOrigins = origins
+	if config.AIBreakerCooldown, err = getEnvDuration("AI_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if config.PendingEvalPeriod, err = getEnvDuration("PENDING_EVAL_INTERVAL", time.Minute); err != nil {
+		return nil, err
+	}
+	if config.EvalSamples, err = getEnvInt("AI_EVAL_SAMPLES", 1); err != nil {
+		return nil, err
+	}
+	if config.ReviewSpread, err = getEnvFloat("AI_REVIEW_SPREAD", 2.0); err != nil {
+		return nil, err
+	}
+	if config.FollowUpMaxDepth, err = getEnvInt("FOLLOW_UP_MAX_DEPTH", 0); err != nil {
+		return nil, err
+	}
+	if config.HintPenalty, err = getEnvFloat("HINT_PENALTY", 1.0); err != nil {
+		return nil, err
+	}
+	if err := loadSandboxLimits(config); err != nil {
+		return nil, err
+	}
+	config.EvalProviders = splitList(strings.ToLower(getEnv("AI_EVAL_PROVIDERS", "")))
 
-	if config.GeminiAPIKey == "" {
+	usesGemini := config.AIProvider == "gemini"
+	for _, name := range config.EvalProviders {
+		usesGemini = usesGemini || name == "gemini"
+	}
+	if usesGemini && config.GeminiAPIKey == "" && config.CassetteMode != "replay" {
 		return nil, fmt.Errorf("GEMINI_API_KEY is required")
 	}
 
 	return config, nil
 }
 
+// LoadRunner loads the configuration of the runner service, which only
+// needs the sandbox limits and its port.
+func LoadRunner() (*Config, error) {
+	godotenv.Load()
+
+	config := &Config{Port: getEnv("PORT", "8081")}
+	if err := loadSandboxLimits(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func loadSandboxLimits(config *Config) error {
+	var err error
+	if config.SandboxTimeout, err = getEnvDuration("SANDBOX_TIMEOUT", 5*time.Second); err != nil {
+		return err
+	}
+	if config.SandboxMemoryMB, err = getEnvInt("SANDBOX_MEMORY_MB", 256); err != nil {
+		return err
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration (e.g. 500ms): %w", key, err)
+	}
+	return d, nil
+}
+
+func getEnvFloat(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", key, err)
+	}
+	return f, nil
+}
+
+// splitList parses a comma-separated list, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			items = append(items, s)
+		}
+	}
+	return items
+}
@@ -0,0 +1,319 @@
+// Package dataset runs SQL queries against the schema and seed data of a
+// SQL question, loaded into a private in-memory SQLite database, and
+// compares their result sets.
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// MaxRows caps the rows a query may return.
+const MaxRows = 1000
+
+// maxValueBytes caps the size of any string or blob a query builds, so a
+// query cannot exhaust memory by growing a single value.
+const maxValueBytes = 1 << 20
+
+// maxPages caps the pages of the main and temporary databases of a
+// connection, 64 MiB each with SQLite's default page size, so neither the
+// seed data nor a query's temporary tables can grow without bound.
+const maxPages = 16384
+
+// heapLimit caps the memory SQLite allocates. The limit is process-global,
+// shared by all queries running at once, and set by LimitMemory.
+const heapLimit = 256 << 20
+
+// maxDuration caps the time loading a dataset and running a query may take
+// together, so a runaway query is stopped even when the caller's context
+// has no deadline. It is a variable so tests can shorten it.
+var maxDuration = 5 * time.Second
+
+// ErrInvalidDataset is returned when the schema or seed data of a dataset
+// cannot be loaded.
+var ErrInvalidDataset = errors.New("invalid dataset")
+
+// Dataset is the schema and seed data a SQL question is asked against.
+type Dataset struct {
+	Schema string // CREATE TABLE statements
+	Seed   string // INSERT statements
+}
+
+// ResultSet is the result of a query with every value rendered as text:
+// NULL as "NULL", whole floats as integers and other floats rounded to six
+// decimal places, so equal results compare equal whichever way they were
+// computed.
+type ResultSet struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+}
+
+// Authorizer action codes of the statements a query may run. SQLite
+// defines them in sqlite3.h; SQLITE_RECURSIVE is missing from the driver.
+const (
+	actionRead      = sqlite3.SQLITE_READ
+	actionSelect    = sqlite3.SQLITE_SELECT
+	actionFunction  = sqlite3.SQLITE_FUNCTION
+	actionRecursive = 33
+)
+
+// loadAuthorizer admits the statements of a dataset's schema and seed
+// data, which are generated by the AI and as untrusted as a query, except
+// ATTACH, DETACH, PRAGMA and extension loading.
+func loadAuthorizer(action int, _, function, _ string) int {
+	switch {
+	case action == sqlite3.SQLITE_ATTACH, action == sqlite3.SQLITE_DETACH, action == sqlite3.SQLITE_PRAGMA:
+		return sqlite3.SQLITE_DENY
+	case action == actionFunction && strings.EqualFold(function, "load_extension"):
+		return sqlite3.SQLITE_DENY
+	default:
+		return sqlite3.SQLITE_OK
+	}
+}
+
+// queryAuthorizer admits only reads.
+func queryAuthorizer(action int, _, function, _ string) int {
+	switch {
+	case action == actionFunction && strings.EqualFold(function, "load_extension"):
+		return sqlite3.SQLITE_DENY
+	case action == actionRead, action == actionSelect, action == actionFunction, action == actionRecursive:
+		return sqlite3.SQLITE_OK
+	default:
+		return sqlite3.SQLITE_DENY
+	}
+}
+
+// LimitMemory caps the memory SQLite may allocate at heapLimit. SQLite
+// keeps the limit in a process-global variable, however it is set, so it
+// applies to every database in the process and is set once at startup
+// rather than with each query.
+func LimitMemory() error {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(fmt.Sprintf("PRAGMA hard_heap_limit = %d", heapLimit))
+	return err
+}
+
+// Query loads ds into a fresh in-memory database and runs query against
+// it. The query may only read: writes, schema changes, ATTACH, PRAGMA and
+// extension loading are refused, and the schema and seed data may not use
+// ATTACH, PRAGMA or extensions either. Both are bounded in size, and in
+// time by ctx and maxDuration: the driver interrupts the running statement
+// with sqlite3_interrupt once the deadline passes.
+func Query(ctx context.Context, ds Dataset, query string) (*ResultSet, error) {
+	ctx, cancel := context.WithTimeout(ctx, maxDuration)
+	defer cancel()
+
+	// Each connection to :memory: is its own database, and this one is
+	// never shared
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	for _, pragma := range []string{
+		"PRAGMA temp_store = MEMORY",
+		fmt.Sprintf("PRAGMA main.max_page_count = %d", maxPages),
+		fmt.Sprintf("PRAGMA temp.max_page_count = %d", maxPages),
+	} {
+		if _, err := conn.ExecContext(ctx, pragma); err != nil {
+			return nil, err
+		}
+	}
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		c := driverConn.(*sqlite3.SQLiteConn)
+		// The attach limit also stops VACUUM INTO, which attaches the file
+		// it writes
+		c.SetLimit(sqlite3.SQLITE_LIMIT_ATTACHED, 0)
+		c.SetLimit(sqlite3.SQLITE_LIMIT_LENGTH, maxValueBytes)
+		c.RegisterAuthorizer(loadAuthorizer)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The driver only interrupts a statement that is running when the
+	// deadline passes, so none is started after it
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+	if _, err := conn.ExecContext(ctx, ds.Schema); err != nil {
+		return nil, fmt.Errorf("%w: schema: %v", ErrInvalidDataset, queryError(ctx, err))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+	if _, err := conn.ExecContext(ctx, ds.Seed); err != nil {
+		return nil, fmt.Errorf("%w: seed data: %v", ErrInvalidDataset, queryError(ctx, err))
+	}
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		driverConn.(*sqlite3.SQLiteConn).RegisterAuthorizer(queryAuthorizer)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &ResultSet{Columns: columns, Rows: [][]string{}}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(result.Rows) == MaxRows {
+			return nil, fmt.Errorf("query returned more than %d rows", MaxRows)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = formatValue(v)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	return result, nil
+}
+
+// queryError reports a query interrupted by its deadline as timed out.
+func queryError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("query timed out")
+	}
+	return err
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		// The driver parses DATE and DATETIME columns
+		if v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 && v.Nanosecond() == 0 {
+			return v.Format("2006-01-02")
+		}
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Comparison is how closely a result set matches the expected one.
+type Comparison struct {
+	Score        float64 // 0-10
+	MatchedRows  int
+	ExpectedRows int
+	ReturnedRows int
+	Reason       string // why the score is not 10, empty for a match
+}
+
+// Compare scores actual against expected. Column names are ignored, since
+// aliases are a matter of style, but columns must come in the same order.
+// Rows are matched as a multiset and the score is the share of rows in
+// common out of the larger result; when ordered, a result with the right
+// rows in the wrong order gets half marks.
+func Compare(expected, actual *ResultSet, ordered bool) Comparison {
+	c := Comparison{ExpectedRows: len(expected.Rows), ReturnedRows: len(actual.Rows)}
+	if len(actual.Columns) != len(expected.Columns) {
+		c.Reason = fmt.Sprintf("returned %d columns, expected %d", len(actual.Columns), len(expected.Columns))
+		return c
+	}
+
+	remaining := make(map[string]int)
+	for _, row := range expected.Rows {
+		remaining[rowKey(row)]++
+	}
+	for _, row := range actual.Rows {
+		if key := rowKey(row); remaining[key] > 0 {
+			remaining[key]--
+			c.MatchedRows++
+		}
+	}
+
+	total := max(c.ExpectedRows, c.ReturnedRows)
+	if total == 0 {
+		c.Score = 10
+		return c
+	}
+	c.Score = 10 * float64(c.MatchedRows) / float64(total)
+
+	switch {
+	case c.MatchedRows < total:
+		c.Reason = fmt.Sprintf("%d of %d expected rows returned, %d rows returned in total", c.MatchedRows, c.ExpectedRows, c.ReturnedRows)
+	case ordered && !sameOrder(expected.Rows, actual.Rows):
+		c.Score /= 2
+		c.Reason = "rows are in the wrong order"
+	}
+	c.Score = math.Round(c.Score*100) / 100
+	return c
+}
+
+func sameOrder(expected, actual [][]string) bool {
+	for i := range expected {
+		if rowKey(expected[i]) != rowKey(actual[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// rowKey encodes row so that rows with different values never share a key.
+func rowKey(row []string) string {
+	return fmt.Sprintf("%q", row)
+}
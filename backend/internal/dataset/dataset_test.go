@@ -0,0 +1,298 @@
+package dataset
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func result(columns []string, rows ...[]string) *ResultSet {
+	if rows == nil {
+		rows = [][]string{}
+	}
+	return &ResultSet{Columns: columns, Rows: rows}
+}
+
+func TestCompare(t *testing.T) {
+	cols := []string{"name", "total"}
+	expected := result(cols, []string{"ann", "3"}, []string{"bob", "2"}, []string{"cy", "1"})
+
+	tests := []struct {
+		name     string
+		expected *ResultSet
+		actual   *ResultSet
+		ordered  bool
+		want     Comparison
+	}{
+		{
+			name:     "match",
+			expected: expected,
+			actual:   result([]string{"n", "t"}, []string{"ann", "3"}, []string{"bob", "2"}, []string{"cy", "1"}),
+			ordered:  true,
+			want:     Comparison{Score: 10, MatchedRows: 3, ExpectedRows: 3, ReturnedRows: 3},
+		},
+		{
+			name:     "unordered match in another order",
+			expected: expected,
+			actual:   result(cols, []string{"cy", "1"}, []string{"ann", "3"}, []string{"bob", "2"}),
+			want:     Comparison{Score: 10, MatchedRows: 3, ExpectedRows: 3, ReturnedRows: 3},
+		},
+		{
+			name:     "ordered match in the wrong order",
+			expected: expected,
+			actual:   result(cols, []string{"cy", "1"}, []string{"ann", "3"}, []string{"bob", "2"}),
+			ordered:  true,
+			want:     Comparison{Score: 5, MatchedRows: 3, ExpectedRows: 3, ReturnedRows: 3, Reason: "rows are in the wrong order"},
+		},
+		{
+			name:     "missing rows",
+			expected: expected,
+			actual:   result(cols, []string{"ann", "3"}),
+			want: Comparison{Score: 3.33, MatchedRows: 1, ExpectedRows: 3, ReturnedRows: 1,
+				Reason: "1 of 3 expected rows returned, 1 rows returned in total"},
+		},
+		{
+			name:     "extra rows",
+			expected: expected,
+			actual: result(cols, []string{"ann", "3"}, []string{"bob", "2"}, []string{"cy", "1"},
+				[]string{"dee", "0"}),
+			want: Comparison{Score: 7.5, MatchedRows: 3, ExpectedRows: 3, ReturnedRows: 4,
+				Reason: "3 of 3 expected rows returned, 4 rows returned in total"},
+		},
+		{
+			name:     "duplicates count once each",
+			expected: expected,
+			actual:   result(cols, []string{"ann", "3"}, []string{"ann", "3"}, []string{"ann", "3"}),
+			want: Comparison{Score: 3.33, MatchedRows: 1, ExpectedRows: 3, ReturnedRows: 3,
+				Reason: "1 of 3 expected rows returned, 3 rows returned in total"},
+		},
+		{
+			name:     "values are not split across columns",
+			expected: result(cols, []string{"a", "b\x00c"}),
+			actual:   result(cols, []string{"a\x00b", "c"}),
+			want: Comparison{Score: 0, MatchedRows: 0, ExpectedRows: 1, ReturnedRows: 1,
+				Reason: "0 of 1 expected rows returned, 1 rows returned in total"},
+		},
+		{
+			name:     "wrong column count",
+			expected: expected,
+			actual:   result([]string{"name"}, []string{"ann"}, []string{"bob"}, []string{"cy"}),
+			want:     Comparison{ExpectedRows: 3, ReturnedRows: 3, Reason: "returned 1 columns, expected 2"},
+		},
+		{
+			name:     "both empty",
+			expected: result(cols),
+			actual:   result(cols),
+			ordered:  true,
+			want:     Comparison{Score: 10},
+		},
+		{
+			name:     "nothing returned",
+			expected: expected,
+			actual:   result(cols),
+			want:     Comparison{Score: 0, ExpectedRows: 3, Reason: "0 of 3 expected rows returned, 0 rows returned in total"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare(tt.expected, tt.actual, tt.ordered); got != tt.want {
+				t.Errorf("Compare() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ds := Dataset{
+		Schema: "CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT, amount REAL, placed DATE);",
+		Seed: "INSERT INTO orders VALUES (1, 'ann', 10.5, '2024-01-02'), (2, 'bob', 3, '2024-01-03'), " +
+			"(3, 'ann', 0.1234567, NULL);",
+	}
+	attached := filepath.Join(t.TempDir(), "escape.db")
+
+	tests := []struct {
+		name    string
+		ds      Dataset
+		query   string
+		want    *ResultSet
+		wantErr string
+		invalid bool
+	}{
+		{
+			name:  "select",
+			ds:    ds,
+			query: "SELECT customer, SUM(amount) FROM orders GROUP BY customer ORDER BY customer",
+			want:  result([]string{"customer", "SUM(amount)"}, []string{"ann", "10.623457"}, []string{"bob", "3"}),
+		},
+		{
+			name:  "dates and nulls",
+			ds:    ds,
+			query: "SELECT id, placed FROM orders ORDER BY id",
+			want:  result([]string{"id", "placed"}, []string{"1", "2024-01-02"}, []string{"2", "2024-01-03"}, []string{"3", "NULL"}),
+		},
+		{
+			name:  "recursive query",
+			ds:    ds,
+			query: "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 3) SELECT i FROM n",
+			want:  result([]string{"i"}, []string{"1"}, []string{"2"}, []string{"3"}),
+		},
+		{
+			name:    "write",
+			ds:      ds,
+			query:   "DELETE FROM orders",
+			wantErr: "not authorized",
+		},
+		{
+			name:    "pragma",
+			ds:      ds,
+			query:   "PRAGMA table_info(orders)",
+			wantErr: "not authorized",
+		},
+		{
+			name:    "attach",
+			ds:      ds,
+			query:   "ATTACH '" + attached + "' AS other",
+			wantErr: "not authorized",
+		},
+		{
+			name:    "load extension",
+			ds:      ds,
+			query:   "SELECT load_extension('/tmp/evil')",
+			wantErr: "not authorized",
+		},
+		{
+			name:    "too many rows",
+			ds:      ds,
+			query:   "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2000) SELECT i FROM n",
+			wantErr: "more than 1000 rows",
+		},
+		{
+			name:    "oversized value",
+			ds:      ds,
+			query:   "SELECT zeroblob(2000000)",
+			wantErr: "too big",
+		},
+		{
+			name:    "attach in schema",
+			ds:      Dataset{Schema: "ATTACH '" + attached + "' AS other; CREATE TABLE other.t (x);", Seed: "SELECT 1;"},
+			query:   "SELECT 1",
+			invalid: true,
+		},
+		{
+			name:    "vacuum into in schema",
+			ds:      Dataset{Schema: "CREATE TABLE t (x); VACUUM INTO '" + attached + "';", Seed: "SELECT 1;"},
+			query:   "SELECT 1",
+			invalid: true,
+		},
+		{
+			name:    "pragma in seed",
+			ds:      Dataset{Schema: ds.Schema, Seed: "PRAGMA writable_schema = ON;"},
+			query:   "SELECT 1",
+			wantErr: "not authorized",
+			invalid: true,
+		},
+		{
+			name:    "extension in seed",
+			ds:      Dataset{Schema: ds.Schema, Seed: "SELECT load_extension('/tmp/evil');"},
+			query:   "SELECT 1",
+			wantErr: "not authorized",
+			invalid: true,
+		},
+		{
+			name: "oversized seed",
+			ds: Dataset{Schema: "CREATE TABLE t (x);", Seed: "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 200000) " +
+				"INSERT INTO t SELECT randomblob(1000) FROM n;"},
+			query:   "SELECT 1",
+			wantErr: "full",
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Query(context.Background(), tt.ds, tt.query)
+			if tt.wantErr != "" || tt.invalid {
+				if err == nil {
+					t.Fatalf("Query() = %v, want an error", got)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				if errors.Is(err, ErrInvalidDataset) != tt.invalid {
+					t.Errorf("errors.Is(%v, ErrInvalidDataset) = %v, want %v", err, !tt.invalid, tt.invalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Query() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if matches, _ := filepath.Glob(attached + "*"); len(matches) > 0 {
+		t.Errorf("a dataset or query created %v", matches)
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := Query(ctx, Dataset{Schema: "CREATE TABLE t (x);", Seed: "SELECT 1;"},
+		"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) SELECT COUNT(*) FROM n")
+	if err == nil || err.Error() != "query timed out" {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+}
+
+func TestQueryDeadline(t *testing.T) {
+	defer func(d time.Duration) { maxDuration = d }(maxDuration)
+	maxDuration = 50 * time.Millisecond
+
+	// A runaway query is stopped without a deadline from the caller
+	start := time.Now()
+	_, err := Query(context.Background(), Dataset{Schema: "CREATE TABLE t (x);", Seed: "SELECT 1;"},
+		"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) SELECT COUNT(*) FROM n")
+	if err == nil || err.Error() != "query timed out" {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("runaway query stopped after %s", elapsed)
+	}
+
+	// So is a runaway seed
+	_, err = Query(context.Background(), Dataset{Schema: "CREATE TABLE t (x);",
+		Seed: "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) INSERT INTO t SELECT COUNT(*) FROM n;"}, "SELECT 1")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("err = %v, want a timeout", err)
+	}
+}
+
+func TestLimitMemory(t *testing.T) {
+	if err := LimitMemory(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The limit applies to every connection, not just the one that set it
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var limit int64
+	if err := db.QueryRow("PRAGMA hard_heap_limit").Scan(&limit); err != nil {
+		t.Fatal(err)
+	}
+	if limit != heapLimit {
+		t.Errorf("hard_heap_limit = %d, want %d", limit, heapLimit)
+	}
+}
@@ -0,0 +1,151 @@
+// Package diagram parses diagram-as-code (Mermaid and PlantUML) found in
+// answers into the components it draws and the edges between them.
+package diagram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Diagram formats.
+const (
+	FormatMermaid  = "mermaid"
+	FormatPlantUML = "plantuml"
+)
+
+// Source is the text of one diagram found in an answer.
+type Source struct {
+	Format string
+	Text   string
+}
+
+// Component is a box, actor, database, ... drawn in a diagram.
+type Component struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// Edge is a connection between two components, by component ID.
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+// Diagram is the structure of a parsed diagram.
+type Diagram struct {
+	Format     string
+	Components []Component
+	Edges      []Edge
+}
+
+// fencePattern matches a Markdown fenced code block and its language tag.
+var fencePattern = regexp.MustCompile("(?s)```[ \\t]*([A-Za-z]*)[ \\t]*\\r?\\n(.*?)```")
+
+// plantUMLPattern matches a PlantUML block outside a code fence.
+var plantUMLPattern = regexp.MustCompile(`(?s)@startuml.*?(@enduml|$)`)
+
+// fenceFormats maps code block tags to diagram formats.
+var fenceFormats = map[string]string{
+	"mermaid":  FormatMermaid,
+	"plantuml": FormatPlantUML,
+	"puml":     FormatPlantUML,
+	"uml":      FormatPlantUML,
+}
+
+// Extract returns every diagram in answer: fenced code blocks tagged
+// mermaid or plantuml, and bare @startuml ... @enduml blocks.
+func Extract(answer string) []Source {
+	var sources []Source
+	rest := answer
+	for _, m := range fencePattern.FindAllStringSubmatch(answer, -1) {
+		rest = strings.Replace(rest, m[0], "", 1)
+		if format, ok := fenceFormats[strings.ToLower(m[1])]; ok {
+			sources = append(sources, Source{Format: format, Text: m[2]})
+		}
+	}
+	for _, block := range plantUMLPattern.FindAllString(rest, -1) {
+		sources = append(sources, Source{Format: FormatPlantUML, Text: block})
+	}
+	return sources
+}
+
+// Parse validates a diagram and extracts its structure. Errors name the
+// offending line.
+func Parse(src Source) (*Diagram, error) {
+	var d *Diagram
+	var err error
+	switch src.Format {
+	case FormatMermaid:
+		d, err = parseMermaid(src.Text)
+	case FormatPlantUML:
+		d, err = parsePlantUML(src.Text)
+	default:
+		return nil, fmt.Errorf("unsupported diagram format %q", src.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(d.Components) == 0 {
+		return nil, fmt.Errorf("diagram has no components")
+	}
+	return d, nil
+}
+
+// builder accumulates components in order of first appearance.
+type builder struct {
+	d     Diagram
+	index map[string]int
+}
+
+func newBuilder(format string) *builder {
+	return &builder{d: Diagram{Format: format}, index: make(map[string]int)}
+}
+
+// component records a component, keeping the first non-empty label given
+// for it.
+func (b *builder) component(id, label string) {
+	label = strings.TrimSpace(label)
+	if i, ok := b.index[id]; ok {
+		if b.d.Components[i].Label == id && label != "" {
+			b.d.Components[i].Label = label
+		}
+		return
+	}
+	if label == "" {
+		label = id
+	}
+	b.index[id] = len(b.d.Components)
+	b.d.Components = append(b.d.Components, Component{ID: id, Label: label})
+}
+
+func (b *builder) edge(from, to, label string) {
+	b.d.Edges = append(b.d.Edges, Edge{From: from, To: to, Label: strings.TrimSpace(label)})
+}
+
+// lines splits text into trimmed lines, numbered from 1, skipping blank
+// lines.
+func lines(text string) []numberedLine {
+	var out []numberedLine
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, numberedLine{n: i + 1, text: line})
+		}
+	}
+	return out
+}
+
+type numberedLine struct {
+	n    int
+	text string
+}
+
+// unquote strips one pair of surrounding double quotes.
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
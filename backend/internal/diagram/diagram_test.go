@@ -0,0 +1,192 @@
+package diagram
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	answer := "Design:\n```mermaid\ngraph LR\nA --> B\n```\n```go\nfunc main() {}\n```\n" +
+		"```puml\n@startuml\n[A] --> [B]\n@enduml\n```\nAlso:\n@startuml\nactor User\n@enduml\n"
+
+	got := Extract(answer)
+	want := []Source{
+		{Format: FormatMermaid, Text: "graph LR\nA --> B\n"},
+		{Format: FormatPlantUML, Text: "@startuml\n[A] --> [B]\n@enduml\n"},
+		{Format: FormatPlantUML, Text: "@startuml\nactor User\n@enduml"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        Source
+		components []Component
+		edges      []Edge
+		wantErr    string
+	}{
+		{
+			name: "mermaid flowchart",
+			src: Source{Format: FormatMermaid, Text: `graph LR
+  %% entry point
+  client[Browser] -->|HTTPS| api(API Gateway)
+  api --> orders & users
+  orders -- SQL --> db[(Postgres)]
+  subgraph backend
+    users -.-> cache{{Redis}}
+  end
+  classDef hot fill:#f00`},
+			components: []Component{
+				{ID: "client", Label: "Browser"}, {ID: "api", Label: "API Gateway"}, {ID: "orders", Label: "orders"},
+				{ID: "users", Label: "users"}, {ID: "db", Label: "Postgres"}, {ID: "cache", Label: "Redis"},
+			},
+			edges: []Edge{
+				{From: "client", To: "api", Label: "HTTPS"}, {From: "api", To: "orders"}, {From: "api", To: "users"},
+				{From: "orders", To: "db", Label: "SQL"}, {From: "users", To: "cache"},
+			},
+		},
+		{
+			name: "mermaid sequence",
+			src: Source{Format: FormatMermaid, Text: `sequenceDiagram
+  participant C as Client
+  C->>API: GET /orders
+  loop retries
+    API-->>C: 503
+  end
+  Note over C: gives up`},
+			components: []Component{{ID: "C", Label: "Client"}, {ID: "API", Label: "API"}},
+			edges:      []Edge{{From: "C", To: "API", Label: "GET /orders"}, {From: "API", To: "C", Label: "503"}},
+		},
+		{
+			name:    "mermaid unsupported diagram",
+			src:     Source{Format: FormatMermaid, Text: "pie\n\"a\": 1"},
+			wantErr: `line 1: unsupported mermaid diagram "pie"`,
+		},
+		{
+			name:    "mermaid unparsable message",
+			src:     Source{Format: FormatMermaid, Text: "sequenceDiagram\nA->>B: hi\nA talks to B"},
+			wantErr: `line 3: cannot parse "A talks to B"`,
+		},
+		{
+			name:    "mermaid unclosed subgraph",
+			src:     Source{Format: FormatMermaid, Text: "graph TD\nsubgraph s\nA --> B"},
+			wantErr: "subgraph is not closed",
+		},
+		{
+			name: "plantuml component",
+			src: Source{Format: FormatPlantUML, Text: `@startuml
+' the public API
+skinparam componentStyle rectangle
+title Orders
+package "Backend" {
+  [API] <<service>>
+  database "Orders DB" as db
+}
+[API] --> db : SQL
+() HTTP - [API]
+note right of db
+  replicated
+end note
+@enduml`},
+			components: []Component{{ID: "API", Label: "API"}, {ID: "db", Label: "Orders DB"}, {ID: "HTTP", Label: "HTTP"}},
+			edges:      []Edge{{From: "API", To: "db", Label: "SQL"}, {From: "HTTP", To: "API"}},
+		},
+		{
+			name: "plantuml deployment with nested elements",
+			src: Source{Format: FormatPlantUML, Text: `@startuml
+node "Kubernetes" as k8s {
+  [Pod]
+  [Sidecar] as sc
+}
+[Pod] -> sc
+@enduml`},
+			components: []Component{{ID: "k8s", Label: "Kubernetes"}, {ID: "Pod", Label: "Pod"}, {ID: "sc", Label: "Sidecar"}},
+			edges:      []Edge{{From: "Pod", To: "sc"}},
+		},
+		{
+			name: "plantuml use case",
+			src: Source{Format: FormatPlantUML, Text: `@startuml
+left to right direction
+actor :Shopper: as S
+usecase (Checkout) as C
+User -> (Login)
+S --> C
+(Login) .> C : include
+@enduml`},
+			components: []Component{
+				{ID: "S", Label: "Shopper"}, {ID: "C", Label: "Checkout"}, {ID: "User", Label: "User"}, {ID: "Login", Label: "Login"},
+			},
+			edges: []Edge{{From: "User", To: "Login"}, {From: "S", To: "C"}, {From: "Login", To: "C", Label: "include"}},
+		},
+		{
+			name: "plantuml sequence",
+			src: Source{Format: FormatPlantUML, Text: `@startuml
+autonumber
+participant Client
+Client -> API : GET /orders
+alt cached
+  API --> Client : 200
+else
+  API -> DB : SELECT
+end
+== Teardown ==
+note over API : done
+@enduml`},
+			components: []Component{{ID: "Client", Label: "Client"}, {ID: "API", Label: "API"}, {ID: "DB", Label: "DB"}},
+			edges: []Edge{
+				{From: "Client", To: "API", Label: "GET /orders"}, {From: "API", To: "Client", Label: "200"},
+				{From: "API", To: "DB", Label: "SELECT"},
+			},
+		},
+		{
+			name:    "plantuml unrecognized line",
+			src:     Source{Format: FormatPlantUML, Text: "@startuml\n[API] --> [DB]\nthe API calls the DB\n@enduml"},
+			wantErr: `line 3: cannot parse "the API calls the DB"`,
+		},
+		{
+			name:    "plantuml missing enduml",
+			src:     Source{Format: FormatPlantUML, Text: "@startuml\n[API] --> [DB]"},
+			wantErr: "must end with @enduml",
+		},
+		{
+			name:    "plantuml unclosed note",
+			src:     Source{Format: FormatPlantUML, Text: "@startuml\n[API]\nnote left of API\ntext\n@enduml"},
+			wantErr: "not closed with end note",
+		},
+		{
+			name:    "plantuml unmatched brace",
+			src:     Source{Format: FormatPlantUML, Text: "@startuml\n[API]\n}\n@enduml"},
+			wantErr: "line 3: unmatched }",
+		},
+		{
+			name:    "plantuml without components",
+			src:     Source{Format: FormatPlantUML, Text: "@startuml\ntitle Empty\n@enduml"},
+			wantErr: "no components",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := Parse(tt.src)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(d.Components, tt.components) {
+				t.Errorf("components = %v, want %v", d.Components, tt.components)
+			}
+			if !reflect.DeepEqual(d.Edges, tt.edges) {
+				t.Errorf("edges = %v, want %v", d.Edges, tt.edges)
+			}
+		})
+	}
+}
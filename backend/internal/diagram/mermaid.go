@@ -0,0 +1,186 @@
+package diagram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	mermaidFlowchartPattern = regexp.MustCompile(`^(graph|flowchart)(\s+(TB|TD|BT|RL|LR))?\s*;?$`)
+	mermaidSequencePattern  = regexp.MustCompile(`^sequenceDiagram\s*$`)
+
+	// mermaidLinkPattern matches a flowchart link with its optional label:
+	// A --> B, A --- B, A -.-> B, A ==> B, A -->|label| B, A -- label --> B
+	mermaidLinkPattern = regexp.MustCompile(`\s*(?:--\s*([^|>\-\s][^>]*?)\s*-->|==\s*([^=>\s][^>]*?)\s*==>|-\.\s*([^.>\s][^>]*?)\s*\.->|(<?(?:-{2,}>|-{3,}|-\.+->|-\.+-|={2,}>|={3,}|--[ox])))(?:\s*\|([^|]*)\|)?\s*`)
+
+	// mermaidNodePattern matches a node reference with an optional shape
+	// and label, e.g. api, api[API Gateway], db[(Postgres)], q{{Queue}}
+	mermaidNodePattern = regexp.MustCompile(`^([A-Za-z0-9_][\w.\-]*)(?:\s*[\[({>]+(.*?)[\])}]+)?(?::::[\w-]+)?$`)
+
+	// mermaidIgnoredPattern matches flowchart statements that draw no
+	// components
+	mermaidIgnoredPattern = regexp.MustCompile(`^(classDef|class|style|linkStyle|click|direction)\s`)
+
+	mermaidParticipantPattern = regexp.MustCompile(`^(participant|actor)\s+(.+?)(?:\s+as\s+(.+))?$`)
+
+	// mermaidMessagePattern matches a sequence diagram message such as
+	// Client->>API: GET /orders
+	mermaidMessagePattern = regexp.MustCompile(`^(.+?)\s*(--?>>|--?>|--?x|--?\))\s*[+-]?\s*(.+?)\s*:\s*(.*)$`)
+
+	// mermaidBlockPattern matches sequence diagram statements closed by end
+	mermaidBlockPattern = regexp.MustCompile(`^(loop|alt|opt|par|critical|break|rect|box)\b`)
+
+	// mermaidSequenceIgnoredPattern matches sequence diagram statements
+	// that draw no components or messages
+	mermaidSequenceIgnoredPattern = regexp.MustCompile(`^(else|and|option|autonumber|activate|deactivate|title|note|Note)\b`)
+)
+
+// parseMermaid parses a Mermaid flowchart or sequence diagram.
+func parseMermaid(text string) (*Diagram, error) {
+	var body []numberedLine
+	for _, l := range lines(text) {
+		if !strings.HasPrefix(l.text, "%%") {
+			body = append(body, l)
+		}
+	}
+	if len(body) == 0 {
+		return nil, fmt.Errorf("mermaid diagram is empty")
+	}
+
+	header := body[0].text
+	switch {
+	case mermaidFlowchartPattern.MatchString(header):
+		return parseMermaidFlowchart(body[1:])
+	case mermaidSequencePattern.MatchString(header):
+		return parseMermaidSequence(body[1:])
+	default:
+		return nil, fmt.Errorf("line %d: unsupported mermaid diagram %q, use a flowchart or sequenceDiagram", body[0].n, header)
+	}
+}
+
+func parseMermaidFlowchart(body []numberedLine) (*Diagram, error) {
+	b := newBuilder(FormatMermaid)
+	subgraphs := 0
+
+	for _, l := range body {
+		line := strings.TrimSuffix(l.text, ";")
+		switch {
+		case strings.HasPrefix(line, "subgraph"):
+			subgraphs++
+			continue
+		case line == "end":
+			if subgraphs == 0 {
+				return nil, fmt.Errorf("line %d: end without subgraph", l.n)
+			}
+			subgraphs--
+			continue
+		case mermaidIgnoredPattern.MatchString(line):
+			continue
+		}
+
+		// Split the line into node groups separated by links
+		links := mermaidLinkPattern.FindAllStringSubmatchIndex(line, -1)
+		var groups [][]string
+		var labels []string
+		start := 0
+		for _, m := range links {
+			group, err := parseMermaidNodes(b, line[start:m[0]])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", l.n, err)
+			}
+			groups = append(groups, group)
+			labels = append(labels, firstGroup(line, m, 1, 2, 3, 5))
+			start = m[1]
+		}
+		group, err := parseMermaidNodes(b, line[start:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", l.n, err)
+		}
+		groups = append(groups, group)
+
+		for i, label := range labels {
+			for _, from := range groups[i] {
+				for _, to := range groups[i+1] {
+					b.edge(from, to, unquote(label))
+				}
+			}
+		}
+	}
+
+	if subgraphs > 0 {
+		return nil, fmt.Errorf("subgraph is not closed with end")
+	}
+	return &b.d, nil
+}
+
+// parseMermaidNodes parses "a", "a[Label]" or "a & b" and records the
+// nodes, returning their IDs.
+func parseMermaidNodes(b *builder, text string) ([]string, error) {
+	var ids []string
+	for _, ref := range strings.Split(text, "&") {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			return nil, fmt.Errorf("link is missing a node")
+		}
+		m := mermaidNodePattern.FindStringSubmatch(ref)
+		if m == nil {
+			return nil, fmt.Errorf("cannot parse node %q", ref)
+		}
+		label := strings.Trim(unquote(m[2]), `/\`)
+		b.component(m[1], unquote(label))
+		ids = append(ids, m[1])
+	}
+	return ids, nil
+}
+
+// firstGroup returns the first non-empty submatch among groups.
+func firstGroup(s string, m []int, groups ...int) string {
+	for _, g := range groups {
+		if m[2*g] >= 0 && m[2*g+1] > m[2*g] {
+			return s[m[2*g]:m[2*g+1]]
+		}
+	}
+	return ""
+}
+
+func parseMermaidSequence(body []numberedLine) (*Diagram, error) {
+	b := newBuilder(FormatMermaid)
+	blocks := 0
+
+	for _, l := range body {
+		line := l.text
+		if m := mermaidParticipantPattern.FindStringSubmatch(line); m != nil {
+			id := unquote(m[2])
+			b.component(id, unquote(m[3]))
+			continue
+		}
+		switch {
+		case mermaidBlockPattern.MatchString(line):
+			blocks++
+			continue
+		case line == "end":
+			if blocks == 0 {
+				return nil, fmt.Errorf("line %d: end without a matching block", l.n)
+			}
+			blocks--
+			continue
+		case mermaidSequenceIgnoredPattern.MatchString(line):
+			continue
+		}
+
+		m := mermaidMessagePattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: cannot parse %q", l.n, line)
+		}
+		from, to := strings.TrimSpace(m[1]), strings.TrimSpace(m[3])
+		b.component(from, "")
+		b.component(to, "")
+		b.edge(from, to, m[4])
+	}
+
+	if blocks > 0 {
+		return nil, fmt.Errorf("block is not closed with end")
+	}
+	return &b.d, nil
+}
@@ -0,0 +1,185 @@
+package diagram
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// plantUMLRef matches an element reference: [Component], "Quoted name",
+// () Interface, (Use case), :Actor: or a plain identifier.
+const plantUMLRef = `(\[[^\]]+\]|"[^"]+"|\(\)\s*[\w.]+|\([^)]+\)|:[^:]+:|[\w.]+)`
+
+// plantUMLDecoration matches what may follow an element declaration: an
+// alias, a stereotype, a color and an opening brace.
+const plantUMLDecoration = `(?:\s+as\s+([\w.]+))?(?:\s*<<[^>]*>>)?(?:\s*#\w+)?\s*\{?$`
+
+var (
+	// plantUMLElementPattern matches element declarations such as
+	// database "Orders DB" as db, or component [API] <<service>>
+	plantUMLElementPattern = regexp.MustCompile(`^(component|node|database|queue|actor|cloud|rectangle|interface|participant|boundary|control|entity|collections|storage|frame|folder|artifact|usecase|agent|card|file|stack|hexagon|package)\s+` +
+		plantUMLRef + plantUMLDecoration)
+
+	// plantUMLBareElementPattern matches an element declared by its
+	// reference alone, e.g. [Pod] inside a node, or (Login) as L
+	plantUMLBareElementPattern = regexp.MustCompile(`^(\[[^\]]+\]|\([^)]+\)|:[^:]+:)` + plantUMLDecoration)
+
+	// plantUMLEdgePattern matches a relation between two elements, e.g.
+	// [API] --> db : SQL, or client -up-> api
+	plantUMLEdgePattern = regexp.MustCompile(`^` + plantUMLRef + `\s*([<*o#]?[-.=]+(?:\[[^\]]*\])?(?:(?:up|down|left|right|u|d|l|r)[-.=]*)?[-.=]*(?:>>|[>*o#])?)\s*` +
+		plantUMLRef + `(?:\s*:\s*(.*))?$`)
+
+	// plantUMLBlockPattern matches sequence diagram statements closed by end
+	plantUMLBlockPattern = regexp.MustCompile(`^(alt|loop|opt|par|break|critical|group)\b`)
+
+	// plantUMLIgnoredPattern matches statements that draw neither elements
+	// nor relations
+	plantUMLIgnoredPattern = regexp.MustCompile(`^(?:(?:skinparam|title|header|footer|caption|scale|hide|show|` +
+		`left to right direction|top to bottom direction|autonumber|activate|deactivate|create|destroy|return|` +
+		`else|newpage|ref over|box|end box|[rh]?note)\b|together\s*\{$|!|\.\.\.|\|\|\||==)`)
+
+	// plantUMLMultiLinePattern matches statements whose text continues
+	// until a closing line: notes without an inline text, a bare title or
+	// legend, and skinparam blocks
+	plantUMLMultiLinePattern = regexp.MustCompile(`^(?:([rh]?note)\s[^:"]*|(title)|(legend)\b.*|(skinparam)\b.*\{)$`)
+)
+
+// plantUMLClosers maps the statements plantUMLMultiLinePattern matches to
+// the prefix of the line that closes them.
+var plantUMLClosers = map[string]string{
+	"note":      "end note",
+	"rnote":     "end rnote",
+	"hnote":     "end hnote",
+	"title":     "end title",
+	"legend":    "end legend",
+	"skinparam": "}",
+}
+
+// parsePlantUML parses a PlantUML component, deployment, use case or
+// sequence diagram between @startuml and @enduml. Statements that draw
+// neither elements nor relations (skinparam, notes, titles, ...) are
+// skipped; any other line that cannot be parsed is an error.
+func parsePlantUML(text string) (*Diagram, error) {
+	all := lines(text)
+	if len(all) == 0 || !strings.HasPrefix(all[0].text, "@startuml") {
+		return nil, fmt.Errorf("plantuml diagram must start with @startuml")
+	}
+	if all[len(all)-1].text != "@enduml" {
+		return nil, fmt.Errorf("plantuml diagram must end with @enduml")
+	}
+
+	b := newBuilder(FormatPlantUML)
+	braces, blocks := 0, 0
+	closer := ""
+
+	for _, l := range all[1 : len(all)-1] {
+		line := l.text
+		if closer != "" {
+			if strings.HasPrefix(line, closer) || closer == "end legend" && line == "endlegend" {
+				closer = ""
+			}
+			continue
+		}
+		if m := plantUMLMultiLinePattern.FindStringSubmatch(line); m != nil {
+			for _, kind := range m[1:] {
+				if kind != "" {
+					closer = plantUMLClosers[kind]
+					break
+				}
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "'"):
+			continue
+		case line == "}":
+			if braces == 0 {
+				return nil, fmt.Errorf("line %d: unmatched }", l.n)
+			}
+			braces--
+			continue
+		case plantUMLBlockPattern.MatchString(line):
+			blocks++
+			continue
+		case line == "end":
+			if blocks == 0 {
+				return nil, fmt.Errorf("line %d: end without a matching block", l.n)
+			}
+			blocks--
+			continue
+		case plantUMLIgnoredPattern.MatchString(line):
+			if strings.HasSuffix(line, "{") {
+				braces++
+			}
+			continue
+		}
+
+		if m := plantUMLElementPattern.FindStringSubmatch(line); m != nil {
+			name := plantUMLName(m[2])
+			id := name
+			if m[3] != "" {
+				id = m[3]
+			}
+			if m[1] != "package" {
+				b.component(id, name)
+			}
+			if strings.HasSuffix(line, "{") {
+				braces++
+			}
+			continue
+		}
+
+		if m := plantUMLBareElementPattern.FindStringSubmatch(line); m != nil {
+			name := plantUMLName(m[1])
+			id := name
+			if m[2] != "" {
+				id = m[2]
+			}
+			b.component(id, name)
+			if strings.HasSuffix(line, "{") {
+				braces++
+			}
+			continue
+		}
+
+		if m := plantUMLEdgePattern.FindStringSubmatch(line); m != nil {
+			from, to := plantUMLName(m[1]), plantUMLName(m[3])
+			b.component(from, "")
+			b.component(to, "")
+			b.edge(from, to, m[4])
+			continue
+		}
+
+		return nil, fmt.Errorf("line %d: cannot parse %q", l.n, line)
+	}
+
+	if closer != "" {
+		return nil, fmt.Errorf("multi-line statement is not closed with %s", closer)
+	}
+	if braces > 0 {
+		return nil, fmt.Errorf("{ is not closed")
+	}
+	if blocks > 0 {
+		return nil, fmt.Errorf("block is not closed with end")
+	}
+	return &b.d, nil
+}
+
+// plantUMLName strips the brackets, parentheses, colons, quotes or
+// interface marker from an element reference.
+func plantUMLName(ref string) string {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case strings.HasPrefix(ref, "["):
+		return strings.TrimSpace(strings.Trim(ref, "[]"))
+	case strings.HasPrefix(ref, "()"):
+		return strings.TrimSpace(ref[2:])
+	case strings.HasPrefix(ref, "("):
+		return strings.TrimSpace(strings.Trim(ref, "()"))
+	case strings.HasPrefix(ref, ":"):
+		return strings.TrimSpace(strings.Trim(ref, ":"))
+	default:
+		return unquote(ref)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/models"
+	"github.com/ai-interviewer/backend/internal/questionbank"
+)
+
+// Running score thresholds for adaptive interviews: at or above
+// stepUpScore the next question is one level harder, below stepDownScore
+// one level easier.
+const (
+	stepUpScore   = 7.5
+	stepDownScore = 5.0
+)
+
+var difficultyLevels = []string{"easy", "medium", "hard"}
+
+// nextDifficulty moves current one level up or down the difficulty scale
+// based on the candidate's running score.
+func nextDifficulty(current string, runningScore float64) string {
+	level := 1
+	for i, d := range difficultyLevels {
+		if d == current {
+			level = i
+		}
+	}
+
+	switch {
+	case runningScore >= stepUpScore && level < len(difficultyLevels)-1:
+		level++
+	case runningScore < stepDownScore && level > 0:
+		level--
+	}
+	return difficultyLevels[level]
+}
+
+// nextAdaptiveQuestion generates and stores the next top-level question of
+// an adaptive interview, or returns nil once it has interviewLength of
+// them. If the AI cannot generate one, a question bank question is used so
+// the interview can go on; the answer has already been stored.
+func (h *Handler) nextAdaptiveQuestion(ctx context.Context, interview *models.Interview, questions []models.Question) (*models.Question, error) {
+	current := interview.Difficulty
+	asked := make([]string, 0, len(questions))
+	topLevel, lastOrder := 0, 0
+	for _, q := range questions {
+		asked = append(asked, q.QuestionText)
+		if q.Depth == 0 {
+			topLevel++
+			if q.TargetDifficulty != "" {
+				current = q.TargetDifficulty
+			}
+		}
+		if q.Order > lastOrder {
+			lastOrder = q.Order
+		}
+	}
+	if topLevel >= interviewLength {
+		return nil, nil
+	}
+
+	// Without any scored answer yet, stay at the current level
+	difficulty := current
+	if runningScore, scored := h.scoredAverage(questions); scored > 0 {
+		difficulty = nextDifficulty(current, runningScore)
+	}
+
+	// Keep tailoring questions to the candidate's resume, if they gave one
+	var profile *ai.Profile
+	if user, err := h.repo.GetUser(interview.UserID); err != nil {
+		log.Printf("Failed to get user %d for interview %d: %v", interview.UserID, interview.ID, err)
+	} else {
+		profile = questionProfile(user.Profile)
+	}
+
+	generated, err := h.aiService.GenerateQuestions(ctx, ai.QuestionRequest{
+		Position:   interview.Position,
+		Difficulty: difficulty,
+		Count:      1,
+		Asked:      asked,
+		Profile:    profile,
+	})
+	if err != nil {
+		log.Printf("Failed to generate adaptive question for interview %d, using question bank: %v", interview.ID, err)
+		generated = questionbank.Pick(difficulty, 1, asked...)
+	}
+	if len(generated) == 0 {
+		return nil, nil
+	}
+
+	g := generated[0]
+	return h.repo.CreateQuestion(models.Question{
+		InterviewID:      interview.ID,
+		QuestionText:     g.Text,
+		QuestionType:     g.Type,
+		Topics:           g.Topics,
+		Difficulty:       g.Difficulty,
+		TargetDifficulty: difficulty,
+		ReferenceAnswer:  g.ReferenceAnswer,
+		KeyPoints:        g.KeyPoints,
+		TestCases:        testCases(g.TestCases),
+		Dataset:          questionDataset(g.Dataset),
+		Options:          g.Options,
+		CorrectOptions:   g.CorrectOptions,
+		AcceptedAnswers:  g.AcceptedAnswers,
+		AnswerPattern:    g.AnswerPattern,
+		Order:            lastOrder + 1,
+	})
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/models"
+	"github.com/ai-interviewer/backend/internal/sandbox"
+)
+
+// maxStoredOutput caps the program output and error text kept per test
+// result.
+const maxStoredOutput = 4096
+
+// codeBlockPattern matches a Markdown fenced code block and its language
+// tag.
+var codeBlockPattern = regexp.MustCompile("(?s)```[ \\t]*([A-Za-z0-9+#.-]*)[ \\t]*\\r?\\n(.*?)```")
+
+// codeLanguages maps code block tags to sandbox languages.
+var codeLanguages = map[string]string{
+	"go":         "go",
+	"golang":     "go",
+	"python":     "python",
+	"python3":    "python",
+	"py":         "python",
+	"javascript": "javascript",
+	"js":         "javascript",
+	"node":       "javascript",
+	"sql":        "sql",
+}
+
+// submitLanguages lists the languages code may be submitted in: those the
+// sandbox can check, and queries to SQL questions.
+var submitLanguages = append(slices.Clone(sandbox.AnalysisLanguages), "sql")
+
+// extractCode returns the first fenced code block in answer tagged with a
+// language the sandbox can check.
+func extractCode(answer string) (language, code string, ok bool) {
+	for _, m := range codeBlockPattern.FindAllStringSubmatch(answer, -1) {
+		if language, ok := codeLanguages[strings.ToLower(m[1])]; ok {
+			return language, m[2], true
+		}
+	}
+	return "", "", false
+}
+
+// answerCode returns the code of an answer: the code submitted with it, or
+// else the first fenced code block in its text.
+func answerCode(response *models.Response) (language, code string) {
+	if response.Code != "" {
+		return response.Language, response.Code
+	}
+	language, code, _ = extractCode(response.ResponseText)
+	return language, code
+}
+
+// answerText is an answer as the AI sees it: its text followed by the code
+// submitted with it, if any.
+func answerText(response *models.Response) string {
+	if response.Code == "" {
+		return response.ResponseText
+	}
+	return strings.TrimSpace(response.ResponseText + "\n\n```" + response.Language + "\n" + response.Code + "\n```")
+}
+
+// analyzeCode runs the static checks for language over code. It returns
+// nil when there is no code or the language has no checks, and an error
+// when the checks could not run.
+func (h *Handler) analyzeCode(ctx context.Context, language, code string) ([]models.Diagnostic, error) {
+	if code == "" || !slices.Contains(sandbox.AnalysisLanguages, language) {
+		return nil, nil
+	}
+
+	found, err := h.sandbox.Analyze(ctx, language, code)
+	if err != nil {
+		log.Printf("Failed to analyze %s code: %v", language, err)
+		return nil, err
+	}
+
+	diagnostics := make([]models.Diagnostic, 0, len(found))
+	for _, d := range found {
+		diagnostics = append(diagnostics, models.Diagnostic{
+			Tool:     d.Tool,
+			Severity: d.Severity,
+			Line:     d.Line,
+			Column:   d.Column,
+			Message:  truncateOutput(d.Message),
+		})
+	}
+	return diagnostics, nil
+}
+
+// testCases converts generated test cases for storage.
+func testCases(generated []ai.TestCase) []models.TestCase {
+	var cases []models.TestCase
+	for _, tc := range generated {
+		cases = append(cases, models.TestCase{Input: tc.Input, ExpectedOutput: tc.ExpectedOutput})
+	}
+	return cases
+}
+
+// runTestCases runs the code in an answer to a coding question against the
+// question's test cases. It returns nil when there is nothing to run, and
+// an error when the code could not be run, for example because the
+// sandbox is unavailable.
+func (h *Handler) runTestCases(ctx context.Context, question *models.Question, language, code string) ([]models.TestResult, error) {
+	if question.QuestionType != "coding" || len(question.TestCases) == 0 {
+		return nil, nil
+	}
+	if code == "" || !slices.Contains(sandbox.Languages, language) {
+		return nil, nil
+	}
+
+	inputs := make([]string, 0, len(question.TestCases))
+	for _, tc := range question.TestCases {
+		inputs = append(inputs, tc.Input)
+	}
+	runs, err := h.sandbox.Execute(ctx, language, code, inputs)
+	var compileErr *sandbox.CompileError
+	if errors.As(err, &compileErr) {
+		// Code that does not compile fails every test
+		results := make([]models.TestResult, 0, len(question.TestCases))
+		for _, tc := range question.TestCases {
+			results = append(results, testResult(tc, "", truncateOutput(compileErr.Error()), 0))
+		}
+		return results, nil
+	}
+	if err == nil && len(runs) != len(inputs) {
+		err = fmt.Errorf("got %d results for %d test cases", len(runs), len(inputs))
+	}
+	if err != nil {
+		log.Printf("Failed to run %s code for question %d: %v", language, question.ID, err)
+		return nil, err
+	}
+
+	results := make([]models.TestResult, 0, len(question.TestCases))
+	for i, tc := range question.TestCases {
+		run := runs[i]
+		errText := ""
+		switch {
+		case run.TimedOut:
+			errText = fmt.Sprintf("timed out after %s", h.cfg.SandboxTimeout)
+		case run.ExitCode != 0:
+			errText = fmt.Sprintf("exited with code %d", run.ExitCode)
+			if stderr := strings.TrimSpace(run.Stderr); stderr != "" {
+				errText += ": " + stderr
+			}
+		}
+		result := testResult(tc, truncateOutput(run.Stdout), truncateOutput(errText), int(run.Duration.Milliseconds()))
+		result.Passed = errText == "" && sandbox.OutputMatches(run.Stdout, tc.ExpectedOutput)
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func testResult(tc models.TestCase, output, errText string, durationMS int) models.TestResult {
+	return models.TestResult{
+		TestCaseID:     tc.ID,
+		Input:          tc.Input,
+		ExpectedOutput: tc.ExpectedOutput,
+		Output:         output,
+		Error:          errText,
+		DurationMS:     durationMS,
+	}
+}
+
+// truncateOutput makes program output safe to store: invalid UTF-8 is
+// replaced and the text is cut to maxStoredOutput bytes.
+func truncateOutput(s string) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	if len(s) <= maxStoredOutput {
+		return s
+	}
+	// Drop a character split by the cut
+	return strings.ToValidUTF8(s[:maxStoredOutput], "") + "\n[output truncated]"
+}
+
+// uncheckedCodeReason explains why an answer whose code could not be
+// analyzed or run is routed to human review.
+func uncheckedCodeReason(analyzeErr, runErr error) string {
+	var skipped []string
+	if analyzeErr != nil {
+		skipped = append(skipped, "analyzed")
+	}
+	if runErr != nil {
+		skipped = append(skipped, "run against the test cases")
+	}
+	reason := "code could not be " + strings.Join(skipped, " or ")
+	switch {
+	case errors.Is(analyzeErr, sandbox.ErrUnavailable) || errors.Is(runErr, sandbox.ErrUnavailable):
+		reason += ": sandbox unavailable"
+	case errors.Is(analyzeErr, sandbox.ErrBusy) || errors.Is(runErr, sandbox.ErrBusy):
+		reason += ": sandbox busy"
+	}
+	return reason
+}
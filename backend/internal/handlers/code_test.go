@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ai-interviewer/backend/internal/sandbox"
+)
+
+func TestExtractCode(t *testing.T) {
+	tests := []struct {
+		answer       string
+		wantLanguage string
+		wantCode     string
+		wantOK       bool
+	}{
+		{"No code here.", "", "", false},
+		{"```go\npackage main\n```", "go", "package main\n", true},
+		{"Like this:\n```Python3\nprint(1)\n```\nthen", "python", "print(1)\n", true},
+		{"```text\nnot code\n```\n```js\nf()\n```", "javascript", "f()\n", true},
+		{"```\nuntagged\n```", "", "", false},
+	}
+	for _, tt := range tests {
+		language, code, ok := extractCode(tt.answer)
+		if language != tt.wantLanguage || code != tt.wantCode || ok != tt.wantOK {
+			t.Errorf("extractCode(%q) = %q, %q, %v, want %q, %q, %v",
+				tt.answer, language, code, ok, tt.wantLanguage, tt.wantCode, tt.wantOK)
+		}
+	}
+}
+
+func TestUncheckedCodeReason(t *testing.T) {
+	unavailable := fmt.Errorf("%w: operation not permitted", sandbox.ErrUnavailable)
+	failed := errors.New("python parser check failed")
+
+	tests := []struct {
+		analyzeErr, runErr error
+		want               string
+	}{
+		{unavailable, nil, "code could not be analyzed: sandbox unavailable"},
+		{nil, unavailable, "code could not be run against the test cases: sandbox unavailable"},
+		{unavailable, unavailable, "code could not be analyzed or run against the test cases: sandbox unavailable"},
+		{sandbox.ErrBusy, nil, "code could not be analyzed: sandbox busy"},
+		{failed, nil, "code could not be analyzed"},
+	}
+	for _, tt := range tests {
+		if got := uncheckedCodeReason(tt.analyzeErr, tt.runErr); got != tt.want {
+			t.Errorf("uncheckedCodeReason(%v, %v) = %q, want %q", tt.analyzeErr, tt.runErr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/diagram"
+	"github.com/ai-interviewer/backend/internal/models"
+)
+
+// parseDiagrams parses the Mermaid and PlantUML diagrams in an answer to a
+// system design question. A diagram that does not parse is kept with its
+// error so the evaluator and the candidate can see why.
+func parseDiagrams(question *models.Question, response *models.Response) []models.Diagram {
+	if question.QuestionType != "system_design" {
+		return nil
+	}
+
+	var diagrams []models.Diagram
+	for _, src := range diagram.Extract(answerText(response)) {
+		d := models.Diagram{
+			Format:     src.Format,
+			Components: []models.DiagramComponent{},
+			Edges:      []models.DiagramEdge{},
+		}
+		parsed, err := diagram.Parse(src)
+		if err != nil {
+			d.Error = truncateOutput(err.Error())
+			diagrams = append(diagrams, d)
+			continue
+		}
+		for _, c := range parsed.Components {
+			d.Components = append(d.Components, models.DiagramComponent{ID: c.ID, Label: c.Label})
+		}
+		for _, e := range parsed.Edges {
+			d.Edges = append(d.Edges, models.DiagramEdge{From: e.From, To: e.To, Label: e.Label})
+		}
+		diagrams = append(diagrams, d)
+	}
+	return diagrams
+}
+
+// evaluationDiagram describes a parsed diagram for the evaluator.
+func evaluationDiagram(d models.Diagram) ai.Diagram {
+	out := ai.Diagram{Format: d.Format, Error: d.Error}
+	for _, c := range d.Components {
+		if c.Label == c.ID {
+			out.Components = append(out.Components, c.ID)
+		} else {
+			out.Components = append(out.Components, fmt.Sprintf("%s (%s)", c.Label, c.ID))
+		}
+	}
+	for _, e := range d.Edges {
+		edge := e.From + " -> " + e.To
+		if e.Label != "" {
+			edge += ": " + e.Label
+		}
+		out.Edges = append(out.Edges, edge)
+	}
+	return out
+}
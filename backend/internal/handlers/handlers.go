@@ -3,29 +3,68 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/config"
 	"github.com/ai-interviewer/backend/internal/models"
+	"github.com/ai-interviewer/backend/internal/questionbank"
 	"github.com/ai-interviewer/backend/internal/repository"
+	"github.com/ai-interviewer/backend/internal/sandbox"
 	"github.com/gorilla/mux"
 )
 
 type Handler struct {
 	repo      *repository.Repository
-	aiService *ai.AIService
+	aiService ai.Provider
+	cfg       *config.Config
+	sandbox   sandbox.Sandbox
 }
 
-func New(repo *repository.Repository, aiService *ai.AIService) *Handler {
+func New(repo *repository.Repository, aiService ai.Provider, cfg *config.Config) *Handler {
+	var runner sandbox.Sandbox = sandbox.New(sandbox.Limits{Timeout: cfg.SandboxTimeout, MemoryMB: cfg.SandboxMemoryMB})
+	if cfg.SandboxURL != "" {
+		runner = sandbox.NewClient(cfg.SandboxURL)
+	}
+
 	return &Handler{
 		repo:      repo,
 		aiService: aiService,
+		cfg:       cfg,
+		sandbox:   runner,
 	}
 }
 
+// interviewLength is the number of top-level questions per interview.
+const interviewLength = 5
+
+// maxReviewReasonLength is the size of the responses.review_reason column,
+// in characters.
+const maxReviewReasonLength = 255
+
+// maxFollowUpDepthLimit bounds the follow-up depth a client may request.
+const maxFollowUpDepthLimit = 3
+
+// maxAnswerBytes is the size of the responses.response_text and
+// responses.code columns.
+const maxAnswerBytes = 65535
+
+// maxStartBytes caps the body of a request to start an interview: a
+// resume of resume.MaxBytes, base64-encoded, and the other fields.
+const maxStartBytes = 3 << 20
+
+// maxSubmitBytes caps the body of a submitted answer: its text and code,
+// escaped as JSON.
+const maxSubmitBytes = 1 << 20
+
 func (h *Handler) StartInterview(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusOK)
@@ -33,7 +72,13 @@ func (h *Handler) StartInterview(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.StartInterviewRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxStartBytes)
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than %d bytes", maxStartBytes))
+			return
+		}
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
@@ -44,6 +89,32 @@ func (h *Handler) StartInterview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Mode == "" {
+		req.Mode = "practice"
+	}
+	if req.Mode != "practice" && req.Mode != "assessment" {
+		respondWithError(w, http.StatusBadRequest, "mode must be practice or assessment")
+		return
+	}
+
+	maxFollowUpDepth := h.cfg.FollowUpMaxDepth
+	if req.MaxFollowUpDepth != nil {
+		maxFollowUpDepth = *req.MaxFollowUpDepth
+	}
+	if maxFollowUpDepth < 0 || maxFollowUpDepth > maxFollowUpDepthLimit {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("max_follow_up_depth must be between 0 and %d", maxFollowUpDepthLimit))
+		return
+	}
+
+	var resume string
+	if req.Resume != nil {
+		var err error
+		if resume, err = resumeText(r.Context(), req.Resume); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
 	// Create or get user
 	user, err := h.repo.CreateUser(req.UserName, req.Email)
 	if err != nil {
@@ -51,27 +122,66 @@ func (h *Handler) StartInterview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create interview
-	interview, err := h.repo.CreateInterview(user.ID, req.Position, req.Difficulty)
+	// Generate questions using AI. Adaptive interviews start with a single
+	// question and generate the rest as answers come in.
+	count := interviewLength
+	if req.Adaptive {
+		count = 1
+	}
+	ctx := context.Background()
+	profile := h.candidateProfile(ctx, user, resume)
+	generated, err := h.aiService.GenerateQuestions(ctx, ai.QuestionRequest{
+		Position:   req.Position,
+		Difficulty: req.Difficulty,
+		Count:      count,
+		Profile:    questionProfile(profile),
+	})
+	if isAIOutage(err) {
+		// Degraded mode: fall back to the built-in question bank
+		log.Printf("AI provider unavailable, using question bank: %v", err)
+		generated, err = questionbank.Pick(req.Difficulty, count), nil
+	}
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to create interview")
+		log.Printf("AI service error: %v", err)
+		respondWithAIError(w, err, fmt.Sprintf("Failed to generate questions: %v", err))
 		return
 	}
 
-	// Generate questions using AI
-	ctx := context.Background()
-	questionTexts, err := h.aiService.GenerateQuestions(ctx, req.Position, req.Difficulty, 5)
+	// Create interview, recording which prompt produced its questions
+	interview, err := h.repo.CreateInterview(models.Interview{
+		UserID:           user.ID,
+		Position:         req.Position,
+		Difficulty:       req.Difficulty,
+		PromptVersion:    generated[0].PromptVersion,
+		MaxFollowUpDepth: maxFollowUpDepth,
+		Adaptive:         req.Adaptive,
+		Mode:             req.Mode,
+	})
 	if err != nil {
-		log.Printf("AI service error: %v", err)
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to generate questions: %v", err))
+		respondWithError(w, http.StatusInternalServerError, "Failed to create interview")
 		return
 	}
 
 	// Store questions in database
 	var questions []models.Question
-	for i, qText := range questionTexts {
-		qType := determineQuestionType(i)
-		question, err := h.repo.CreateQuestion(interview.ID, qText, qType, i+1)
+	for i, g := range generated {
+		question, err := h.repo.CreateQuestion(models.Question{
+			InterviewID:      interview.ID,
+			QuestionText:     g.Text,
+			QuestionType:     g.Type,
+			Topics:           g.Topics,
+			Difficulty:       g.Difficulty,
+			TargetDifficulty: req.Difficulty,
+			ReferenceAnswer:  g.ReferenceAnswer,
+			KeyPoints:        g.KeyPoints,
+			TestCases:        testCases(g.TestCases),
+			Dataset:          questionDataset(g.Dataset),
+			Options:          g.Options,
+			CorrectOptions:   g.CorrectOptions,
+			AcceptedAnswers:  g.AcceptedAnswers,
+			AnswerPattern:    g.AnswerPattern,
+			Order:            i + 1,
+		})
 		if err != nil {
 			respondWithError(w, http.StatusInternalServerError, "Failed to store questions")
 			return
@@ -83,6 +193,7 @@ func (h *Handler) StartInterview(w http.ResponseWriter, r *http.Request) {
 	response := models.StartInterviewResponse{
 		InterviewID: interview.ID,
 		Question:    questions[0],
+		Profile:     profile,
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
@@ -93,12 +204,22 @@ func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
-	
+
 	var req models.SubmitAnswerRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxSubmitBytes)
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body is larger than %d bytes", maxSubmitBytes))
+			return
+		}
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	if len(req.ResponseText) > maxAnswerBytes || len(req.Code) > maxAnswerBytes {
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("response_text and code must each be at most %d bytes", maxAnswerBytes))
+		return
+	}
 
 	// Get question
 	question, err := h.repo.GetQuestion(req.QuestionID)
@@ -107,21 +228,111 @@ func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	interview, err := h.repo.GetInterview(question.InterviewID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get interview")
+		return
+	}
+	if interview.Status == "completed" {
+		respondWithError(w, http.StatusConflict, "Interview is already completed")
+		return
+	}
+	responses, err := h.repo.GetQuestionResponses(question.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get responses")
+		return
+	}
+	if len(responses) > 0 {
+		respondWithError(w, http.StatusConflict, "Question has already been answered")
+		return
+	}
+
+	hints, err := h.repo.GetQuestionHints(question.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get hints")
+		return
+	}
+
+	if req.Code != "" && !slices.Contains(submitLanguages, req.Language) {
+		respondWithError(w, http.StatusBadRequest, "language must be one of "+strings.Join(submitLanguages, ", "))
+		return
+	}
+	if msg := checkSelection(question, req.SelectedOptions); msg != "" {
+		respondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
 	// Evaluate answer using AI
 	ctx := context.Background()
-	feedback, score, err := h.aiService.EvaluateAnswer(ctx, question.QuestionText, req.ResponseText)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to evaluate answer")
+	answer := models.Response{
+		QuestionID:       req.QuestionID,
+		ResponseText:     req.ResponseText,
+		Language:         req.Language,
+		Code:             req.Code,
+		Status:           "scored",
+		Strengths:        []string{},
+		Weaknesses:       []string{},
+		Rubric:           []models.RubricScore{},
+		KeyPointsCovered: []string{},
+		KeyPointsMissed:  []string{},
+	}
+	if req.Code == "" {
+		// A language without code describes nothing
+		answer.Language = ""
+	}
+	if isChoice(question) {
+		answer.SelectedOptions = req.SelectedOptions
+		answer.ResponseText = selectedText(question, req.SelectedOptions)
+	}
+	language, code := answerCode(&answer)
+	diagnostics, analyzeErr := h.analyzeCode(ctx, language, code)
+	results, runErr := h.runTestCases(ctx, question, language, code)
+	answer.Diagnostics, answer.TestResults = diagnostics, results
+	if analyzeErr != nil || runErr != nil {
+		// The answer is graded without the checks the code could not get
+		flagForReview(&answer, uncheckedCodeReason(analyzeErr, runErr))
+	}
+	answer.Diagrams = parseDiagrams(question, &answer)
+	answer.QueryResult = h.runQuery(ctx, question, &answer, language, code)
+	if isQuiz(question) {
+		// Quiz answers are graded against the answer key, without the AI
+		h.gradeQuiz(question, &answer, len(hints))
+	} else if eval, err := h.aiService.EvaluateAnswer(ctx, evaluationRequest(question, &answer, hints)); errors.Is(err, ai.ErrUnscored) {
+		// Keep the answer but don't invent a score for it
+		log.Printf("Answer to question %d could not be scored: %v", req.QuestionID, err)
+		answer.Status = "unscored"
+		answer.Feedback = "We could not score this answer automatically."
+		flagInjection(&answer, false)
+	} else if isAIOutage(err) {
+		// Keep the answer and score it once the provider recovers
+		log.Printf("AI provider unavailable, answer to question %d is pending evaluation: %v", req.QuestionID, err)
+		answer.Status = "pending"
+		answer.Feedback = "Your answer has been saved and will be scored once the AI service is available again."
+	} else if err != nil {
+		log.Printf("AI service error: %v", err)
+		respondWithAIError(w, err, "Failed to evaluate answer")
 		return
+	} else {
+		h.applyEvaluation(&answer, eval, len(hints))
 	}
 
 	// Store response
-	_, err = h.repo.CreateResponse(req.QuestionID, req.ResponseText, feedback, score)
+	stored, err := h.repo.CreateResponse(answer)
+	if errors.Is(err, repository.ErrAlreadyAnswered) {
+		respondWithError(w, http.StatusConflict, "Question has already been answered")
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to store response")
 		return
 	}
 
+	// Probe shallow or ambiguous answers before moving on. Quiz answers are
+	// right or wrong, with nothing to probe
+	if stored.Status != "pending" && !isQuiz(question) {
+		h.askFollowUp(ctx, interview, question, stored)
+	}
+
 	// Get all questions for this interview
 	questions, err := h.repo.GetInterviewQuestions(question.InterviewID)
 	if err != nil {
@@ -131,6 +342,7 @@ func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
 
 	// Check if there are more questions
 	var nextQuestion *models.Question
+	var finalFeedback *models.FinalFeedback
 	completed := false
 
 	for i, q := range questions {
@@ -140,24 +352,18 @@ func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if nextQuestion == nil && interview.Adaptive {
+		nextQuestion, err = h.nextAdaptiveQuestion(ctx, interview, questions)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to store next question")
+			return
+		}
+	}
+
 	if nextQuestion == nil {
 		// Interview completed - calculate average score
 		completed = true
-		totalScore := 0.0
-		count := 0
-
-		for _, q := range questions {
-			responses, err := h.repo.GetQuestionResponses(q.ID)
-			if err == nil && len(responses) > 0 && responses[0].Score != nil {
-				totalScore += *responses[0].Score
-				count++
-			}
-		}
-
-		avgScore := 0.0
-		if count > 0 {
-			avgScore = totalScore / float64(count)
-		}
+		avgScore := h.averageScore(questions)
 
 		// Update interview status
 		err = h.repo.UpdateInterviewStatus(question.InterviewID, "completed", avgScore)
@@ -165,13 +371,26 @@ func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
 			respondWithError(w, http.StatusInternalServerError, "Failed to update interview")
 			return
 		}
+
+		finalFeedback = h.generateFinalFeedback(ctx, question.InterviewID, questions, avgScore)
 	}
 
 	response := models.SubmitAnswerResponse{
-		Feedback:     feedback,
-		Score:        score,
-		NextQuestion: nextQuestion,
-		Completed:    completed,
+		Feedback:         stored.Feedback,
+		Score:            stored.Score,
+		Status:           stored.Status,
+		Strengths:        stored.Strengths,
+		Weaknesses:       stored.Weaknesses,
+		Rubric:           stored.Rubric,
+		KeyPointsCovered: stored.KeyPointsCovered,
+		KeyPointsMissed:  stored.KeyPointsMissed,
+		TestResults:      stored.TestResults,
+		Diagnostics:      stored.Diagnostics,
+		Diagrams:         stored.Diagrams,
+		QueryResult:      stored.QueryResult,
+		NextQuestion:     nextQuestion,
+		Completed:        completed,
+		FinalFeedback:    finalFeedback,
 	}
 
 	respondWithJSON(w, http.StatusOK, response)
@@ -222,21 +441,266 @@ func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 }
 
 // Helper functions
+
+// averageScore averages the scored answers to questions. Unscored and
+// pending answers are left out.
+func (h *Handler) averageScore(questions []models.Question) float64 {
+	avg, _ := h.scoredAverage(questions)
+	return avg
+}
+
+// scoredAverage is averageScore that also reports how many answers were
+// scored.
+func (h *Handler) scoredAverage(questions []models.Question) (float64, int) {
+	totalScore := 0.0
+	count := 0
+
+	for _, q := range questions {
+		responses, err := h.repo.GetQuestionResponses(q.ID)
+		if err == nil && len(responses) > 0 && responses[0].Score != nil {
+			totalScore += *responses[0].Score
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, 0
+	}
+	return totalScore / float64(count), count
+}
+
+// askFollowUp asks the AI whether an answer needs a probing follow-up and,
+// if so, inserts it right after the question as a child question. Nothing
+// is asked once the interview's maximum follow-up depth is reached. Errors
+// are logged; the interview then simply moves on.
+func (h *Handler) askFollowUp(ctx context.Context, interview *models.Interview, question *models.Question, answer *models.Response) {
+	if question.Depth >= interview.MaxFollowUpDepth {
+		return
+	}
+	followUp, err := h.aiService.GenerateFollowUp(ctx, ai.FollowUpRequest{
+		Position: interview.Position,
+		Question: question.QuestionText,
+		Type:     question.QuestionType,
+		Answer:   answerText(answer),
+		Feedback: answer.Feedback,
+		Score:    answer.Score,
+	})
+	if err != nil {
+		log.Printf("Failed to generate follow-up for question %d: %v", question.ID, err)
+		return
+	}
+	if followUp == nil {
+		return
+	}
+
+	parentID := question.ID
+	_, err = h.repo.CreateFollowUp(models.Question{
+		InterviewID:     question.InterviewID,
+		QuestionText:    followUp.Text,
+		QuestionType:    followUp.Type,
+		Topics:          followUp.Topics,
+		Difficulty:      followUp.Difficulty,
+		ReferenceAnswer: followUp.ReferenceAnswer,
+		KeyPoints:       followUp.KeyPoints,
+		ParentID:        &parentID,
+		Depth:           question.Depth + 1,
+		Order:           question.Order + 1,
+	})
+	if errors.Is(err, repository.ErrFollowUpExists) {
+		// The question already has a follow-up
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to store follow-up to question %d: %v", question.ID, err)
+	}
+}
+
+// generateFinalFeedback summarizes a completed interview from its full
+// transcript and stores the result. Failures are logged and return nil; the
+// interview stays completed without final feedback.
+func (h *Handler) generateFinalFeedback(ctx context.Context, interviewID int, questions []models.Question, avgScore float64) *models.FinalFeedback {
+	interview, err := h.repo.GetInterview(interviewID)
+	if err != nil {
+		log.Printf("Failed to load interview %d for final feedback: %v", interviewID, err)
+		return nil
+	}
+
+	req := ai.FinalFeedbackRequest{
+		Position:     interview.Position,
+		Difficulty:   interview.Difficulty,
+		AverageScore: avgScore,
+	}
+	for _, q := range questions {
+		entry := ai.TranscriptEntry{Question: q.QuestionText, Type: q.QuestionType}
+		responses, err := h.repo.GetQuestionResponses(q.ID)
+		if err == nil && len(responses) > 0 {
+			entry.Answer = answerText(&responses[0])
+			entry.Feedback = responses[0].Feedback
+			entry.Score = responses[0].Score
+		}
+		req.Transcript = append(req.Transcript, entry)
+	}
+
+	generated, err := h.aiService.GenerateFinalFeedback(ctx, req)
+	if err != nil {
+		log.Printf("Failed to generate final feedback for interview %d: %v", interviewID, err)
+		return nil
+	}
+
+	feedback := models.FinalFeedback{
+		Summary:        generated.Summary,
+		Strengths:      generated.Strengths,
+		Improvements:   generated.Improvements,
+		Recommendation: generated.Recommendation,
+		PromptVersion:  generated.PromptVersion,
+	}
+	if err := h.repo.UpdateInterviewFeedback(interviewID, feedback); err != nil {
+		log.Printf("Failed to store final feedback for interview %d: %v", interviewID, err)
+		return nil
+	}
+
+	return &feedback
+}
+
+// evaluationRequest builds the evaluator input for an answer to question.
+func evaluationRequest(question *models.Question, answer *models.Response, hints []models.Hint) ai.EvaluationRequest {
+	req := ai.EvaluationRequest{
+		Question:        question.QuestionText,
+		Answer:          answerText(answer),
+		ReferenceAnswer: question.ReferenceAnswer,
+		KeyPoints:       question.KeyPoints,
+	}
+	for _, hint := range hints {
+		req.Hints = append(req.Hints, hint.Text)
+	}
+	if len(answer.Diagnostics) > 0 {
+		req.Language, _ = answerCode(answer)
+	}
+	for _, d := range answer.Diagnostics {
+		req.Diagnostics = append(req.Diagnostics, ai.Diagnostic{
+			Tool:     d.Tool,
+			Severity: d.Severity,
+			Line:     d.Line,
+			Message:  d.Message,
+		})
+	}
+	for _, res := range answer.TestResults {
+		req.TestResults = append(req.TestResults, ai.TestResult{
+			Input:          res.Input,
+			ExpectedOutput: res.ExpectedOutput,
+			Output:         res.Output,
+			Passed:         res.Passed,
+			Error:          res.Error,
+		})
+	}
+	for _, d := range answer.Diagrams {
+		req.Diagrams = append(req.Diagrams, evaluationDiagram(d))
+	}
+	if q := answer.QueryResult; q != nil {
+		req.QueryCheck = &ai.QueryCheck{Score: q.Score, Reason: q.Reason, Error: q.Error}
+	}
+	return req
+}
+
+// applyEvaluation copies an AI evaluation onto a response and marks it
+// scored. The correctness of SQL answers is the deterministic score of
+// their query rather than the AI's. The score is reduced by the hint
+// penalty for every hint used, but never below 0; the rubric keeps the
+// unpenalized scores.
+func (h *Handler) applyEvaluation(response *models.Response, eval *ai.Evaluation, hintsUsed int) {
+	response.Rubric = make([]models.RubricScore, 0, len(eval.Rubric))
+	for _, r := range eval.Rubric {
+		response.Rubric = append(response.Rubric, models.RubricScore{
+			Dimension:     r.Dimension,
+			Score:         r.Score,
+			Justification: r.Justification,
+		})
+	}
+	rawScore := eval.Score
+	if response.QueryResult != nil {
+		rawScore = overrideCorrectness(response.Rubric, response.QueryResult)
+	}
+
+	response.HintPenalty = float64(hintsUsed) * h.cfg.HintPenalty
+	score := math.Max(0, math.Round((rawScore-response.HintPenalty)*100)/100)
+
+	response.Status = "scored"
+	response.Feedback = eval.Feedback
+	response.Score = &score
+	response.Strengths = eval.Strengths
+	response.Weaknesses = eval.Weaknesses
+	response.Samples = eval.Samples
+	response.FailedSamples = eval.FailedSamples
+	if eval.Samples > 1 {
+		spread := eval.ScoreSpread
+		response.ScoreSpread = &spread
+	}
+	if eval.NeedsReview {
+		flagForReview(response, eval.ReviewReason)
+	}
+	response.KeyPointsCovered = eval.KeyPointsCovered
+	response.KeyPointsMissed = eval.KeyPointsMissed
+	response.PromptVersion = eval.PromptVersion
+	flagInjection(response, eval.InjectionDetected)
+}
+
+// flagInjection marks a response whose text looks like a prompt-injection
+// attempt, or that the grader reported as one, and routes it to human
+// review.
+func flagInjection(response *models.Response, graderDetected bool) {
+	reasons := ai.DetectInjection(answerText(response))
+	if graderDetected {
+		reasons = append(reasons, "grader reported instructions in the answer")
+	}
+	if len(reasons) == 0 {
+		return
+	}
+
+	response.InjectionFlagged = true
+	flagForReview(response, ai.InjectionReviewReason(reasons))
+}
+
+// flagForReview routes a response to human review, adding reason to the
+// reasons it already has.
+func flagForReview(response *models.Response, reason string) {
+	response.NeedsReview = true
+	if response.ReviewReason != "" {
+		reason = response.ReviewReason + "; " + reason
+	}
+	if utf8.RuneCountInString(reason) > maxReviewReasonLength {
+		reason = string([]rune(reason)[:maxReviewReasonLength])
+	}
+	response.ReviewReason = reason
+}
+
+// isAIOutage reports whether err means the AI provider is down or out of
+// quota, as opposed to a bad request or unusable output.
+func isAIOutage(err error) bool {
+	return errors.Is(err, ai.ErrUnavailable) || errors.Is(err, ai.ErrQuotaExceeded)
+}
+
 func respondWithError(w http.ResponseWriter, code int, message string) {
 	respondWithJSON(w, code, map[string]string{"error": message})
 }
 
+// respondWithAIError maps classified AI provider errors to 429/503 so
+// clients can tell a temporary outage from a server bug.
+func respondWithAIError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ai.ErrQuotaExceeded):
+		w.Header().Set("Retry-After", "60")
+		respondWithError(w, http.StatusTooManyRequests, "AI provider quota exceeded, please try again later")
+	case errors.Is(err, ai.ErrUnavailable):
+		w.Header().Set("Retry-After", "10")
+		respondWithError(w, http.StatusServiceUnavailable, "AI provider is temporarily unavailable, please try again")
+	default:
+		respondWithError(w, http.StatusInternalServerError, message)
+	}
+}
+
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	w.Write(response)
 }
-
-func determineQuestionType(index int) string {
-	types := []string{"technical", "behavioral", "technical", "coding", "behavioral"}
-	if index < len(types) {
-		return types[index]
-	}
-	return "technical"
-}
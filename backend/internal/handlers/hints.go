@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/models"
+	"github.com/ai-interviewer/backend/internal/repository"
+	"github.com/gorilla/mux"
+)
+
+// maxHints is the number of hints a candidate can ask for per question.
+const maxHints = 3
+
+// errNoMoreHints is returned by nextHint once every hint has been given.
+var errNoMoreHints = errors.New("no more hints available for this question")
+
+// RequestHint returns the next, stronger hint for an unanswered question.
+// Every hint used lowers the answer's score by the configured penalty.
+// Hints are only available in practice mode, and not for quiz questions,
+// which a hint would simply answer.
+func (h *Handler) RequestHint(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid question ID")
+		return
+	}
+
+	question, err := h.repo.GetQuestion(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Question not found")
+		return
+	}
+
+	if isQuiz(question) {
+		respondWithError(w, http.StatusBadRequest, "Hints are not available for quiz questions")
+		return
+	}
+
+	interview, err := h.repo.GetInterview(question.InterviewID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get interview")
+		return
+	}
+	if interview.Mode == "assessment" {
+		respondWithError(w, http.StatusForbidden, "Hints are not available in assessment mode")
+		return
+	}
+
+	responses, err := h.repo.GetQuestionResponses(question.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get responses")
+		return
+	}
+	if len(responses) > 0 {
+		respondWithError(w, http.StatusConflict, "Question has already been answered")
+		return
+	}
+
+	hint, err := h.nextHint(context.Background(), question)
+	if errors.Is(err, errNoMoreHints) {
+		respondWithError(w, http.StatusConflict, "No more hints available for this question")
+		return
+	}
+	if errors.Is(err, repository.ErrHintExists) {
+		respondWithError(w, http.StatusConflict, "Another hint for this question was just given; request the next one")
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to give hint for question %d: %v", question.ID, err)
+		respondWithAIError(w, err, "Failed to generate hint")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, models.HintResponse{
+		Hint:           *hint,
+		HintsUsed:      hint.Level,
+		MaxHints:       maxHints,
+		PenaltyPerHint: h.cfg.HintPenalty,
+	})
+}
+
+// nextHint generates and stores the hint that follows the ones already
+// given for question.
+func (h *Handler) nextHint(ctx context.Context, question *models.Question) (*models.Hint, error) {
+	given, err := h.repo.GetQuestionHints(question.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(given) >= maxHints {
+		return nil, errNoMoreHints
+	}
+
+	req := ai.HintRequest{
+		Question:        question.QuestionText,
+		ReferenceAnswer: question.ReferenceAnswer,
+		KeyPoints:       question.KeyPoints,
+		MaxHints:        maxHints,
+	}
+	for _, hint := range given {
+		req.Previous = append(req.Previous, hint.Text)
+	}
+
+	generated, err := h.aiService.GenerateHint(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.repo.CreateHint(models.Hint{
+		QuestionID:    question.ID,
+		Level:         len(given) + 1,
+		Text:          generated.Text,
+		PromptVersion: generated.PromptVersion,
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/ai-interviewer/backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func TestRequestHint(t *testing.T) {
+	h := newTestHandler(t)
+	user, err := h.repo.CreateUser("Ada Lovelace", "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	interview, err := h.repo.CreateInterview(models.Interview{
+		UserID: user.ID, Position: "Backend Engineer", Difficulty: "medium", Mode: "practice",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	question, err := h.repo.CreateQuestion(models.Question{
+		InterviewID:     interview.ID,
+		QuestionText:    scriptedQuestions[0].Text,
+		QuestionType:    "technical",
+		ReferenceAnswer: "Resources, HTTP verbs and status codes.",
+		KeyPoints:       scriptedQuestions[0].KeyPoints,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := strconv.Itoa(question.ID)
+	for level := 1; level <= maxHints; level++ {
+		var got models.HintResponse
+		do(t, h.RequestHint, http.MethodPost, "/api/interview/question/"+id+"/hint", map[string]string{"id": id}, nil, &got)
+		if got.Hint.Level != level || got.HintsUsed != level || got.Hint.Text == "" {
+			t.Errorf("hint %d: got level %d, %d used, text %q", level, got.Hint.Level, got.HintsUsed, got.Hint.Text)
+		}
+	}
+
+	hints, err := h.repo.GetQuestionHints(question.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hints) != maxHints {
+		t.Fatalf("%d hints stored, want %d", len(hints), maxHints)
+	}
+	for _, hint := range hints {
+		if hint.PromptVersion != "hint-v2" {
+			t.Errorf("hint %d stored with prompt version %q, want hint-v2", hint.Level, hint.PromptVersion)
+		}
+	}
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/interview/question/"+id+"/hint", http.NoBody),
+		map[string]string{"id": id})
+	rec := httptest.NewRecorder()
+	h.RequestHint(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("hint %d: status = %d, want %d", maxHints+1, rec.Code, http.StatusConflict)
+	}
+}
+
+func TestRequestHintRejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		questionType string
+		want         int
+	}{
+		{"assessment mode", "assessment", "technical", http.StatusForbidden},
+		{"single choice", "practice", "single_choice", http.StatusBadRequest},
+		{"multi choice", "practice", "multi_choice", http.StatusBadRequest},
+		{"short answer", "practice", "short_answer", http.StatusBadRequest},
+	}
+
+	h := newTestHandler(t)
+	user, err := h.repo.CreateUser("Ada Lovelace", "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interview, err := h.repo.CreateInterview(models.Interview{
+				UserID: user.ID, Position: "Backend Engineer", Difficulty: "medium", Mode: tt.mode,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			question, err := h.repo.CreateQuestion(models.Question{
+				InterviewID: interview.ID, QuestionText: "Which HTTP verb is idempotent?", QuestionType: tt.questionType,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id := strconv.Itoa(question.ID)
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/interview/question/"+id+"/hint", http.NoBody),
+				map[string]string{"id": id})
+			rec := httptest.NewRecorder()
+			h.RequestHint(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// GetModelAnswer reveals an exemplary answer to an evaluated question,
+// with an explanation of how the candidate's answer differs from it. It is
+// only available in practice mode. The answer is generated once and then
+// served from the database.
+func (h *Handler) GetModelAnswer(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid question ID")
+		return
+	}
+
+	question, err := h.repo.GetQuestion(id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Question not found")
+		return
+	}
+
+	interview, err := h.repo.GetInterview(question.InterviewID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get interview")
+		return
+	}
+	if interview.Mode == "assessment" {
+		respondWithError(w, http.StatusForbidden, "Model answers are not available in assessment mode")
+		return
+	}
+
+	responses, err := h.repo.GetQuestionResponses(question.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get responses")
+		return
+	}
+	if len(responses) == 0 {
+		respondWithError(w, http.StatusConflict, "Question has not been answered yet")
+		return
+	}
+	response := responses[0]
+	if response.Status != "scored" {
+		respondWithError(w, http.StatusConflict, "Answer has not been evaluated yet")
+		return
+	}
+
+	existing, err := h.repo.GetModelAnswer(response.ID)
+	if err == nil {
+		respondWithJSON(w, http.StatusOK, existing)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get model answer")
+		return
+	}
+
+	generated, err := h.aiService.GenerateModelAnswer(context.Background(), ai.ModelAnswerRequest{
+		Question:        question.QuestionText,
+		ReferenceAnswer: question.ReferenceAnswer,
+		KeyPoints:       question.KeyPoints,
+		Answer:          answerText(&response),
+		Feedback:        response.Feedback,
+	})
+	if err != nil {
+		log.Printf("Failed to generate model answer for question %d: %v", question.ID, err)
+		respondWithAIError(w, err, "Failed to generate model answer")
+		return
+	}
+
+	stored, err := h.repo.CreateModelAnswer(models.ModelAnswer{
+		ResponseID:    response.ID,
+		Answer:        generated.Answer,
+		Explanation:   generated.Explanation,
+		PromptVersion: generated.PromptVersion,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to store model answer")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, stored)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+)
+
+// pendingBatchSize caps how many pending answers are scored per pass.
+const pendingBatchSize = 20
+
+// RunPendingEvaluations periodically scores answers that were stored as
+// "pending" while the AI provider was unavailable. It blocks until ctx is
+// cancelled.
+func (h *Handler) RunPendingEvaluations(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.evaluatePending(ctx)
+		}
+	}
+}
+
+func (h *Handler) evaluatePending(ctx context.Context) {
+	pending, err := h.repo.GetPendingResponses(pendingBatchSize)
+	if err != nil {
+		log.Printf("Failed to load pending responses: %v", err)
+		return
+	}
+
+	rescored := make(map[int]bool)
+	for _, response := range pending {
+		question, err := h.repo.GetQuestion(response.QuestionID)
+		if err != nil {
+			log.Printf("Failed to load question %d for pending response %d: %v", response.QuestionID, response.ID, err)
+			continue
+		}
+
+		hints, err := h.repo.GetQuestionHints(question.ID)
+		if err != nil {
+			log.Printf("Failed to load hints for pending response %d: %v", response.ID, err)
+			continue
+		}
+
+		eval, err := h.aiService.EvaluateAnswer(ctx, evaluationRequest(question, &response, hints))
+		if isAIOutage(err) {
+			// Still down, try again on the next pass
+			break
+		}
+		if errors.Is(err, ai.ErrUnscored) {
+			response.Status = "unscored"
+			response.Feedback = "We could not score this answer automatically."
+			flagInjection(&response, false)
+		} else if err != nil {
+			log.Printf("Failed to evaluate pending response %d: %v", response.ID, err)
+			continue
+		} else {
+			h.applyEvaluation(&response, eval, len(hints))
+		}
+
+		if err := h.repo.UpdateResponseEvaluation(response); err != nil {
+			log.Printf("Failed to store evaluation for pending response %d: %v", response.ID, err)
+			continue
+		}
+		rescored[question.InterviewID] = true
+	}
+
+	// Refresh the average and final feedback of interviews that were
+	// already completed
+	for interviewID := range rescored {
+		interview, err := h.repo.GetInterview(interviewID)
+		if err != nil || interview.Status != "completed" {
+			continue
+		}
+		questions, err := h.repo.GetInterviewQuestions(interviewID)
+		if err != nil {
+			continue
+		}
+		avgScore := h.averageScore(questions)
+		if err := h.repo.UpdateInterviewScore(interviewID, avgScore); err != nil {
+			log.Printf("Failed to update score for interview %d: %v", interviewID, err)
+			continue
+		}
+		h.generateFinalFeedback(ctx, interviewID, questions, avgScore)
+	}
+}
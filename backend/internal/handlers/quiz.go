@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/models"
+)
+
+// isQuiz reports whether question is graded against its answer key rather
+// than by the AI.
+func isQuiz(question *models.Question) bool {
+	return slices.Contains(ai.QuizTypes, question.QuestionType)
+}
+
+// isChoice reports whether question is answered by choosing options.
+func isChoice(question *models.Question) bool {
+	return question.QuestionType == "single_choice" || question.QuestionType == "multi_choice"
+}
+
+// checkSelection validates the options chosen in an answer to a choice
+// question, returning a message for the candidate if they are invalid.
+func checkSelection(question *models.Question, selected []int) string {
+	if !isChoice(question) {
+		return ""
+	}
+	if len(selected) == 0 {
+		return "selected_options is required for choice questions"
+	}
+	if question.QuestionType == "single_choice" && len(selected) != 1 {
+		return "select exactly one option"
+	}
+	seen := make(map[int]bool)
+	for _, i := range selected {
+		if i < 0 || i >= len(question.Options) || seen[i] {
+			return fmt.Sprintf("invalid option %d", i)
+		}
+		seen[i] = true
+	}
+	return ""
+}
+
+// selectedText is the text of the options chosen in an answer, one per
+// line, so choice answers read like any other in transcripts.
+func selectedText(question *models.Question, selected []int) string {
+	texts := make([]string, 0, len(selected))
+	for _, i := range selected {
+		texts = append(texts, question.Options[i])
+	}
+	return strings.Join(texts, "\n")
+}
+
+// gradeQuiz grades an answer to a quiz question against the question's
+// answer key and marks it scored. Like AI scores, the score is reduced by
+// the hint penalty for every hint used, but never below 0.
+func (h *Handler) gradeQuiz(question *models.Question, response *models.Response, hintsUsed int) {
+	var raw float64
+	var feedback string
+	if isChoice(question) {
+		raw, feedback = gradeChoice(question.CorrectOptions, response.SelectedOptions)
+	} else {
+		raw, feedback = gradeShortAnswer(question, response.ResponseText)
+	}
+
+	response.HintPenalty = float64(hintsUsed) * h.cfg.HintPenalty
+	score := math.Max(0, math.Round((raw-response.HintPenalty)*100)/100)
+	response.Status = "scored"
+	response.Feedback = feedback
+	response.Score = &score
+}
+
+// gradeChoice scores a choice answer: each correct option chosen earns its
+// share of 10 points and each incorrect one costs as much, down to 0.
+func gradeChoice(correct, selected []int) (float64, string) {
+	hits, misses := 0, 0
+	for _, i := range selected {
+		if slices.Contains(correct, i) {
+			hits++
+		} else {
+			misses++
+		}
+	}
+	if hits == len(correct) && misses == 0 {
+		return 10, "Correct."
+	}
+	if len(correct) == 1 {
+		return 0, "Incorrect."
+	}
+
+	score := 10 * math.Max(0, float64(hits-misses)) / float64(len(correct))
+	verdict := "Partly correct"
+	if score == 0 {
+		verdict = "Incorrect"
+	}
+	return score, fmt.Sprintf("%s. Correct options chosen: %d of %d; incorrect options chosen: %d.", verdict, hits, len(correct), misses)
+}
+
+// gradeShortAnswer scores a short answer 10 if it equals an accepted
+// answer, ignoring case and extra spaces, or matches the answer pattern in
+// full, and 0 otherwise.
+func gradeShortAnswer(question *models.Question, answer string) (float64, string) {
+	normalized := normalizeShortAnswer(answer)
+	for _, accepted := range question.AcceptedAnswers {
+		if normalizeShortAnswer(accepted) == normalized {
+			return 10, "Correct."
+		}
+	}
+	if question.AnswerPattern != "" {
+		// Validated when the question was generated
+		pattern, err := regexp.Compile(`(?i)^(?:` + question.AnswerPattern + `)$`)
+		if err == nil && pattern.MatchString(strings.TrimSpace(answer)) {
+			return 10, "Correct."
+		}
+	}
+	return 0, "Incorrect."
+}
+
+func normalizeShortAnswer(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/ai-interviewer/backend/internal/config"
+	"github.com/ai-interviewer/backend/internal/models"
+)
+
+func TestCheckSelection(t *testing.T) {
+	options := []string{"a", "b", "c"}
+	tests := []struct {
+		questionType string
+		selected     []int
+		want         string
+	}{
+		{"single_choice", []int{1}, ""},
+		{"single_choice", nil, "selected_options is required for choice questions"},
+		{"single_choice", []int{0, 1}, "select exactly one option"},
+		{"single_choice", []int{3}, "invalid option 3"},
+		{"multi_choice", []int{0, 2}, ""},
+		{"multi_choice", []int{2, 2}, "invalid option 2"},
+		{"multi_choice", []int{-1}, "invalid option -1"},
+		{"short_answer", nil, ""},
+		{"technical", []int{7}, ""},
+	}
+	for _, tt := range tests {
+		question := &models.Question{QuestionType: tt.questionType, Options: options}
+		if got := checkSelection(question, tt.selected); got != tt.want {
+			t.Errorf("checkSelection(%s, %v) = %q, want %q", tt.questionType, tt.selected, got, tt.want)
+		}
+	}
+}
+
+func TestGradeChoice(t *testing.T) {
+	tests := []struct {
+		correct, selected []int
+		wantScore         float64
+		wantFeedback      string
+	}{
+		{[]int{1}, []int{1}, 10, "Correct."},
+		{[]int{1}, []int{2}, 0, "Incorrect."},
+		{[]int{0, 2}, []int{2, 0}, 10, "Correct."},
+		{[]int{0, 2}, []int{0}, 5, "Partly correct. Correct options chosen: 1 of 2; incorrect options chosen: 0."},
+		{[]int{0, 1, 2, 3}, []int{0, 1, 2, 4}, 5, "Partly correct. Correct options chosen: 3 of 4; incorrect options chosen: 1."},
+		{[]int{0, 2}, []int{0, 1}, 0, "Incorrect. Correct options chosen: 1 of 2; incorrect options chosen: 1."},
+		{[]int{0, 2}, []int{1, 3}, 0, "Incorrect. Correct options chosen: 0 of 2; incorrect options chosen: 2."},
+	}
+	for _, tt := range tests {
+		score, feedback := gradeChoice(tt.correct, tt.selected)
+		if score != tt.wantScore || feedback != tt.wantFeedback {
+			t.Errorf("gradeChoice(%v, %v) = %v, %q, want %v, %q", tt.correct, tt.selected, score, feedback, tt.wantScore, tt.wantFeedback)
+		}
+	}
+}
+
+func TestGradeShortAnswer(t *testing.T) {
+	question := &models.Question{
+		QuestionType:    "short_answer",
+		AcceptedAnswers: []string{"Binary Search", "O(log n)"},
+		AnswerPattern:   `log(arithmic)?( time)?`,
+	}
+	tests := []struct {
+		answer string
+		want   float64
+	}{
+		{"binary search", 10},
+		{"  Binary   SEARCH ", 10},
+		{"o(log n)", 10},
+		{"Logarithmic time", 10},
+		{" log ", 10},
+		{"not logarithmic", 0},
+		{"logarithmic time, probably", 0},
+		{"linear search", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got, _ := gradeShortAnswer(question, tt.answer); got != tt.want {
+			t.Errorf("gradeShortAnswer(%q) = %v, want %v", tt.answer, got, tt.want)
+		}
+	}
+
+	// A pattern that does not compile grades on the accepted answers alone
+	broken := &models.Question{QuestionType: "short_answer", AcceptedAnswers: []string{"yes"}, AnswerPattern: `(`}
+	if got, _ := gradeShortAnswer(broken, "("); got != 0 {
+		t.Errorf("answer to an invalid pattern scored %v, want 0", got)
+	}
+}
+
+func TestGradeQuiz(t *testing.T) {
+	h := &Handler{cfg: &config.Config{HintPenalty: 1.5}}
+	choice := &models.Question{QuestionType: "multi_choice", Options: []string{"a", "b", "c"}, CorrectOptions: []int{0, 2}}
+	short := &models.Question{QuestionType: "short_answer", AcceptedAnswers: []string{"42"}}
+
+	tests := []struct {
+		name         string
+		question     *models.Question
+		response     models.Response
+		hintsUsed    int
+		wantScore    float64
+		wantPenalty  float64
+		wantFeedback string
+	}{
+		{"choice", choice, models.Response{SelectedOptions: []int{0, 2}}, 0, 10, 0, "Correct."},
+		{"choice with hints", choice, models.Response{SelectedOptions: []int{0}}, 2, 2, 3,
+			"Partly correct. Correct options chosen: 1 of 2; incorrect options chosen: 0."},
+		{"short answer", short, models.Response{ResponseText: " 42 "}, 1, 8.5, 1.5, "Correct."},
+		{"penalty never goes below 0", short, models.Response{ResponseText: "41"}, 3, 0, 4.5, "Incorrect."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := tt.response
+			h.gradeQuiz(tt.question, &response, tt.hintsUsed)
+			if response.Score == nil || *response.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %v", response.Score, tt.wantScore)
+			}
+			if response.HintPenalty != tt.wantPenalty {
+				t.Errorf("HintPenalty = %v, want %v", response.HintPenalty, tt.wantPenalty)
+			}
+			if response.Status != "scored" || response.Feedback != tt.wantFeedback {
+				t.Errorf("Status, Feedback = %q, %q, want scored, %q", response.Status, response.Feedback, tt.wantFeedback)
+			}
+		})
+	}
+}
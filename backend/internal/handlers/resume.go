@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/models"
+	"github.com/ai-interviewer/backend/internal/resume"
+)
+
+// resumeText returns the plain text of an uploaded resume, or an error
+// wrapping resume.ErrInvalidResume that can be shown to the candidate.
+func resumeText(ctx context.Context, upload *models.ResumeUpload) (string, error) {
+	data := []byte(upload.Data)
+	if upload.Format == "pdf" {
+		var err error
+		data, err = base64.StdEncoding.DecodeString(upload.Data)
+		if err != nil {
+			return "", fmt.Errorf("%w: pdf data is not valid base64", resume.ErrInvalidResume)
+		}
+	}
+	return resume.Text(ctx, upload.Format, data)
+}
+
+// candidateProfile returns the profile to tailor a new interview's
+// questions to. The profile extracted from a newly uploaded resume
+// replaces the one stored on the user; without an upload the stored one
+// is used. If extraction fails, the stored profile is used as well.
+func (h *Handler) candidateProfile(ctx context.Context, user *models.User, text string) *models.Profile {
+	if text == "" {
+		return user.Profile
+	}
+
+	extracted, err := h.aiService.ExtractProfile(ctx, ai.ProfileRequest{Resume: text})
+	if err != nil {
+		log.Printf("Failed to extract resume profile for user %d: %v", user.ID, err)
+		return user.Profile
+	}
+
+	profile := &models.Profile{
+		Skills:        extracted.Skills,
+		Projects:      make([]models.Project, 0, len(extracted.Projects)),
+		PromptVersion: extracted.PromptVersion,
+		UpdatedAt:     time.Now(),
+	}
+	for _, p := range extracted.Projects {
+		profile.Projects = append(profile.Projects, models.Project{Name: p.Name, Summary: p.Summary, Technologies: p.Technologies})
+	}
+	if err := h.repo.UpdateUserProfile(user.ID, profile); err != nil {
+		log.Printf("Failed to store resume profile for user %d: %v", user.ID, err)
+	}
+	user.Profile = profile
+	return profile
+}
+
+// questionProfile converts a stored profile for question generation.
+func questionProfile(profile *models.Profile) *ai.Profile {
+	if profile == nil {
+		return nil
+	}
+	converted := &ai.Profile{Skills: profile.Skills, Projects: make([]ai.ProfileProject, 0, len(profile.Projects))}
+	for _, p := range profile.Projects {
+		converted.Projects = append(converted.Projects, ai.ProfileProject{Name: p.Name, Summary: p.Summary, Technologies: p.Technologies})
+	}
+	return converted
+}
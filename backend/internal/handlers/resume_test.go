@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ai-interviewer/backend/internal/models"
+)
+
+func TestStartInterviewResume(t *testing.T) {
+	h := newTestHandler(t)
+	start := models.StartInterviewRequest{
+		UserName:   "Ada Lovelace",
+		Email:      "ada@example.com",
+		Position:   "Backend Engineer",
+		Difficulty: "medium",
+		Resume: &models.ResumeUpload{
+			Format: "markdown",
+			Data:   "# Ada Lovelace\n\nGo and MySQL developer.\n\n## Projects\n\n- Ledger: a double-entry payments ledger",
+		},
+	}
+
+	var started models.StartInterviewResponse
+	do(t, h.StartInterview, http.MethodPost, "/api/interview/start", nil, start, &started)
+	if started.Profile == nil || !reflect.DeepEqual(started.Profile.Skills, scriptedProfile.Skills) ||
+		len(started.Profile.Projects) != 1 || started.Profile.Projects[0].Name != "Ledger" {
+		t.Fatalf("profile = %+v, want the one read from the resume", started.Profile)
+	}
+
+	interview, err := h.repo.GetInterview(started.InterviewID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := h.repo.GetUser(interview.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Profile == nil || !reflect.DeepEqual(user.Profile.Skills, scriptedProfile.Skills) || user.Profile.PromptVersion != "profile-v2" {
+		t.Errorf("stored profile = %+v, want the one read from the resume", user.Profile)
+	}
+
+	// A later interview without a resume reuses the stored profile
+	start.Resume = nil
+	var restarted models.StartInterviewResponse
+	do(t, h.StartInterview, http.MethodPost, "/api/interview/start", nil, start, &restarted)
+	if restarted.Profile == nil || !reflect.DeepEqual(restarted.Profile.Skills, scriptedProfile.Skills) {
+		t.Errorf("profile = %+v, want the stored one", restarted.Profile)
+	}
+}
+
+func TestStartInterviewResumeRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		resume models.ResumeUpload
+		want   int
+	}{
+		{"unknown format", models.ResumeUpload{Format: "docx", Data: "Ada"}, http.StatusBadRequest},
+		{"pdf not base64", models.ResumeUpload{Format: "pdf", Data: "%PDF-1.4"}, http.StatusBadRequest},
+		{"malformed pdf", models.ResumeUpload{Format: "pdf", Data: "JVBERi0xLjQKbm90IGEgcGRm"}, http.StatusBadRequest},
+		{"body too large", models.ResumeUpload{Format: "text", Data: strings.Repeat("a", maxStartBytes)}, http.StatusRequestEntityTooLarge},
+	}
+
+	h := newTestHandler(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(models.StartInterviewRequest{
+				UserName:   "Ada Lovelace",
+				Email:      "ada@example.com",
+				Position:   "Backend Engineer",
+				Difficulty: "medium",
+				Resume:     &tt.resume,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := httptest.NewRecorder()
+			h.StartInterview(rec, httptest.NewRequest(http.MethodPost, "/api/interview/start", bytes.NewReader(body)))
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
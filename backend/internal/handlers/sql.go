@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+	"github.com/ai-interviewer/backend/internal/dataset"
+	"github.com/ai-interviewer/backend/internal/models"
+)
+
+// maxStoredRows caps the rows of a candidate's query result kept with the
+// response.
+const maxStoredRows = 20
+
+// bareQueryPattern matches an answer that is nothing but a query.
+var bareQueryPattern = regexp.MustCompile(`(?is)^\s*(select|with)\b`)
+
+// questionDataset converts a generated dataset for storage.
+func questionDataset(generated *ai.SQLDataset) *models.Dataset {
+	if generated == nil || generated.Expected == nil {
+		return nil
+	}
+	return &models.Dataset{
+		Schema:          generated.Schema,
+		Seed:            generated.Seed,
+		Solution:        generated.Solution,
+		Ordered:         generated.Ordered,
+		ExpectedColumns: generated.Expected.Columns,
+		ExpectedRows:    generated.Expected.Rows,
+	}
+}
+
+// answerQuery returns the query in an answer to a SQL question: the code
+// submitted as sql, or else the first sql code block, or else the whole
+// answer if it is a bare query.
+func answerQuery(response *models.Response, language, code string) string {
+	if language == "sql" {
+		return code
+	}
+	if bareQueryPattern.MatchString(response.ResponseText) {
+		return response.ResponseText
+	}
+	return ""
+}
+
+// runQuery runs the query in an answer to a SQL question against the
+// question's dataset and scores its result. It returns nil for other
+// questions and when the dataset itself cannot be loaded; the answer is
+// then graded by the AI alone.
+func (h *Handler) runQuery(ctx context.Context, question *models.Question, response *models.Response, language, code string) *models.QueryResult {
+	if question.QuestionType != "sql" || question.Dataset == nil {
+		return nil
+	}
+	expected := &dataset.ResultSet{Columns: question.Dataset.ExpectedColumns, Rows: question.Dataset.ExpectedRows}
+	result := &models.QueryResult{ExpectedRows: len(expected.Rows), Columns: []string{}, Rows: [][]string{}}
+
+	query := strings.TrimSpace(answerQuery(response, language, code))
+	if query == "" {
+		result.Error = "the answer contains no SQL query"
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.SandboxTimeout)
+	defer cancel()
+	ds := dataset.Dataset{Schema: question.Dataset.Schema, Seed: question.Dataset.Seed}
+	actual, err := dataset.Query(ctx, ds, query)
+	if errors.Is(err, dataset.ErrInvalidDataset) {
+		log.Printf("Failed to load the dataset of question %d: %v", question.ID, err)
+		return nil
+	}
+	if err != nil {
+		result.Error = truncateOutput(err.Error())
+		return result
+	}
+
+	c := dataset.Compare(expected, actual, question.Dataset.Ordered)
+	result.Score = c.Score
+	result.MatchedRows = c.MatchedRows
+	result.ReturnedRows = c.ReturnedRows
+	result.Reason = c.Reason
+	result.Columns = actual.Columns
+	result.Rows = actual.Rows[:min(len(actual.Rows), maxStoredRows)]
+	return result
+}
+
+// overrideCorrectness replaces the AI's correctness score with the
+// deterministic score of a SQL answer and returns the new mean of the
+// rubric.
+func overrideCorrectness(rubric []models.RubricScore, result *models.QueryResult) float64 {
+	justification := "The query returned the expected result."
+	switch {
+	case result.Error != "":
+		justification = "The query could not be checked: " + result.Error
+	case result.Reason != "":
+		justification = "The query result differs from the expected one: " + result.Reason + "."
+	}
+
+	total := 0.0
+	for i := range rubric {
+		if rubric[i].Dimension == "correctness" {
+			rubric[i].Score = result.Score
+			rubric[i].Justification = justification
+		}
+		total += rubric[i].Score
+	}
+	if len(rubric) == 0 {
+		return result.Score
+	}
+	return total / float64(len(rubric))
+}
@@ -8,36 +8,215 @@ type User struct {
 	ID        int       `json:"id"`
 	Name      string    `json:"name"`
 	Email     string    `json:"email"`
+	Profile   *Profile  `json:"profile,omitempty"` // extracted from the last resume uploaded
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Profile is the experience a user claims in their resume, used to tailor
+// interview questions
+type Profile struct {
+	Skills        []string  `json:"skills"`
+	Projects      []Project `json:"projects"`
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Project is a project listed in a resume
+type Project struct {
+	Name         string   `json:"name"`
+	Summary      string   `json:"summary,omitempty"`
+	Technologies []string `json:"technologies"`
+}
+
 type Interview struct {
-	ID          int        `json:"id"`
-	UserID      int        `json:"user_id"`
-	Position    string     `json:"position"`
-	Difficulty  string     `json:"difficulty"` // easy, medium, hard
-	Status      string     `json:"status"`     // in_progress, completed
-	Score       *float64   `json:"score,omitempty"`
-	StartedAt   time.Time  `json:"started_at"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ID               int            `json:"id"`
+	UserID           int            `json:"user_id"`
+	Position         string         `json:"position"`
+	Difficulty       string         `json:"difficulty"` // easy, medium, hard
+	Status           string         `json:"status"`     // in_progress, completed
+	Score            *float64       `json:"score,omitempty"`
+	PromptVersion    string         `json:"prompt_version,omitempty"` // template that generated the questions
+	MaxFollowUpDepth int            `json:"max_follow_up_depth"`      // 0 disables follow-up questions
+	Adaptive         bool           `json:"adaptive"`                 // questions generated one at a time, difficulty follows the running score
+	Mode             string         `json:"mode"`                     // practice, assessment
+	FinalFeedback    *FinalFeedback `json:"final_feedback,omitempty"`
+	StartedAt        time.Time      `json:"started_at"`
+	CompletedAt      *time.Time     `json:"completed_at,omitempty"`
 }
 
 type Question struct {
-	ID           int       `json:"id"`
-	InterviewID  int       `json:"interview_id"`
-	QuestionText string    `json:"question_text"`
-	QuestionType string    `json:"question_type"` // technical, behavioral, coding
-	Order        int       `json:"order"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID               int        `json:"id"`
+	InterviewID      int        `json:"interview_id"`
+	QuestionText     string     `json:"question_text"`
+	QuestionType     string     `json:"question_type"` // technical, behavioral, coding, system_design, sql, single_choice, multi_choice, short_answer
+	Topics           []string   `json:"topics"`
+	Difficulty       string     `json:"difficulty,omitempty"`        // AI-estimated easy, medium, hard
+	TargetDifficulty string     `json:"target_difficulty,omitempty"` // difficulty the question was generated for
+	ReferenceAnswer  string     `json:"-"`                           // hidden from candidates, grounds the evaluation
+	KeyPoints        []string   `json:"-"`
+	TestCases        []TestCase `json:"-"`                   // run against the code in answers to coding questions
+	Dataset          *Dataset   `json:"dataset,omitempty"`   // what answers to SQL questions are run against
+	Options          []string   `json:"options,omitempty"`   // choices of single_choice and multi_choice questions
+	CorrectOptions   []int      `json:"-"`                   // zero-based indexes into Options
+	AcceptedAnswers  []string   `json:"-"`                   // correct short answers
+	AnswerPattern    string     `json:"-"`                   // regular expression a correct short answer matches
+	ParentID         *int       `json:"parent_id,omitempty"` // set on follow-up questions
+	Depth            int        `json:"depth"`               // 0 for top-level questions
+	Order            int        `json:"order"`
+	CreatedAt        time.Time  `json:"created_at"`
 }
 
 type Response struct {
-	ID           int       `json:"id"`
-	QuestionID   int       `json:"question_id"`
-	ResponseText string    `json:"response_text"`
-	Feedback     string    `json:"feedback,omitempty"`
-	Score        *float64  `json:"score,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID               int           `json:"id"`
+	QuestionID       int           `json:"question_id"`
+	ResponseText     string        `json:"response_text"`
+	Language         string        `json:"language,omitempty"`         // language of Code
+	Code             string        `json:"code,omitempty"`             // code submitted alongside the text
+	SelectedOptions  []int         `json:"selected_options,omitempty"` // options chosen in a choice question
+	Feedback         string        `json:"feedback,omitempty"`
+	Score            *float64      `json:"score,omitempty"`
+	Status           string        `json:"status"` // scored, unscored, pending
+	Strengths        []string      `json:"strengths"`
+	Weaknesses       []string      `json:"weaknesses"`
+	Rubric           []RubricScore `json:"rubric"`
+	KeyPointsCovered []string      `json:"key_points_covered"`
+	KeyPointsMissed  []string      `json:"key_points_missed"`
+	TestResults      []TestResult  `json:"test_results"`
+	Diagnostics      []Diagnostic  `json:"diagnostics"`
+	Diagrams         []Diagram     `json:"diagrams"`
+	QueryResult      *QueryResult  `json:"query_result,omitempty"` // outcome of the query in an answer to a SQL question
+	ScoreSpread      *float64      `json:"score_spread,omitempty"` // range of ensemble sample scores
+	Samples          int           `json:"samples,omitempty"`
+	FailedSamples    int           `json:"failed_samples,omitempty"` // ensemble samples that returned no evaluation
+	NeedsReview      bool          `json:"needs_review"`
+	ReviewReason     string        `json:"review_reason,omitempty"`
+	HintPenalty      float64       `json:"hint_penalty"`             // points deducted for hints used
+	InjectionFlagged bool          `json:"injection_flagged"`        // answer tried to instruct the grader
+	PromptVersion    string        `json:"prompt_version,omitempty"` // template that produced the evaluation
+	CreatedAt        time.Time     `json:"created_at"`
+}
+
+// FinalFeedback is the AI summary of a completed interview
+type FinalFeedback struct {
+	Summary        string   `json:"summary"`
+	Strengths      []string `json:"strengths"`
+	Improvements   []string `json:"improvements"`
+	Recommendation string   `json:"recommendation"` // hire, consider, no_hire
+	PromptVersion  string   `json:"prompt_version,omitempty"`
+}
+
+// Hint is one of the progressively stronger hints given for a question
+type Hint struct {
+	ID            int       `json:"id"`
+	QuestionID    int       `json:"question_id"`
+	Level         int       `json:"level"` // 1 for the first hint
+	Text          string    `json:"hint_text"`
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ModelAnswer is an exemplary answer to a question, revealed to practice
+// candidates after their response is evaluated
+type ModelAnswer struct {
+	ID            int       `json:"id"`
+	ResponseID    int       `json:"response_id"`
+	Answer        string    `json:"model_answer"`
+	Explanation   string    `json:"explanation"` // how the candidate's answer differs
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TestCase is a hidden stdin/stdout check of a solution to a coding
+// question
+type TestCase struct {
+	ID             int    `json:"id"`
+	QuestionID     int    `json:"question_id"`
+	Input          string `json:"input"`
+	ExpectedOutput string `json:"expected_output"`
+}
+
+// Dataset is the SQLite schema and seed data of a SQL question. Candidates
+// see the schema only.
+type Dataset struct {
+	QuestionID      int        `json:"-"`
+	Schema          string     `json:"schema"`
+	Seed            string     `json:"-"`
+	Solution        string     `json:"-"`
+	Ordered         bool       `json:"ordered"` // whether the order of the result rows matters
+	ExpectedColumns []string   `json:"-"`
+	ExpectedRows    [][]string `json:"-"`
+}
+
+// QueryResult is the outcome of running the query in an answer against the
+// question's dataset. Score is the deterministic correctness score.
+type QueryResult struct {
+	ID           int        `json:"id"`
+	ResponseID   int        `json:"response_id"`
+	Score        float64    `json:"score"` // 0-10
+	MatchedRows  int        `json:"matched_rows"`
+	ExpectedRows int        `json:"expected_rows"`
+	ReturnedRows int        `json:"returned_rows"`
+	Columns      []string   `json:"columns"`
+	Rows         [][]string `json:"rows"` // the first rows the query returned
+	Reason       string     `json:"reason,omitempty"`
+	Error        string     `json:"error,omitempty"` // why the query failed to run
+}
+
+// TestResult is the outcome of running the code in an answer against one
+// test case. The test cases are hidden, and a program can echo its input,
+// so only whether each one passed is sent to the client
+type TestResult struct {
+	ID             int    `json:"id"`
+	ResponseID     int    `json:"response_id"`
+	TestCaseID     int    `json:"test_case_id"`
+	Input          string `json:"-"`
+	ExpectedOutput string `json:"-"`
+	Output         string `json:"-"`
+	Passed         bool   `json:"passed"`
+	Error          string `json:"-"` // compile error, crash or timeout
+	DurationMS     int    `json:"duration_ms"`
+}
+
+// Diagnostic is a formatter, linter or parser finding about the code in an
+// answer
+type Diagnostic struct {
+	ID         int    `json:"id"`
+	ResponseID int    `json:"response_id"`
+	Tool       string `json:"tool"`     // gofmt, go vet, python parser, node --check
+	Severity   string `json:"severity"` // error, warning
+	Line       int    `json:"line,omitempty"`
+	Column     int    `json:"column,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Diagram is the structure of a Mermaid or PlantUML diagram in an answer to
+// a system design question. Error is set, and the structure empty, when
+// the diagram source is invalid.
+type Diagram struct {
+	ID         int                `json:"id"`
+	ResponseID int                `json:"response_id"`
+	Format     string             `json:"format"` // mermaid, plantuml
+	Components []DiagramComponent `json:"components"`
+	Edges      []DiagramEdge      `json:"edges"`
+	Error      string             `json:"error,omitempty"`
+}
+
+type DiagramComponent struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type DiagramEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+// RubricScore is one dimension of a response's rubric evaluation
+type RubricScore struct {
+	Dimension     string  `json:"dimension"` // correctness, depth, communication, problem_solving, best_practices
+	Score         float64 `json:"score"`
+	Justification string  `json:"justification"`
 }
 
 // DTOs
@@ -46,27 +225,81 @@ type StartInterviewRequest struct {
 	Email      string `json:"email"`
 	Position   string `json:"position"`
 	Difficulty string `json:"difficulty"`
+
+	// MaxFollowUpDepth overrides the server default when set
+	MaxFollowUpDepth *int `json:"max_follow_up_depth,omitempty"`
+
+	// Adaptive generates questions one at a time, adjusting difficulty to
+	// the running score
+	Adaptive bool `json:"adaptive"`
+
+	// Mode is "practice" (default) or "assessment"
+	Mode string `json:"mode"`
+
+	// Resume, when given, is used to tailor the questions to the
+	// candidate's experience and replaces the profile stored on the user
+	Resume *ResumeUpload `json:"resume,omitempty"`
+}
+
+// ResumeUpload is a resume attached to a StartInterviewRequest
+type ResumeUpload struct {
+	Format string `json:"format"` // text, markdown, pdf
+	Data   string `json:"data"`   // the text itself, or base64 for pdf
 }
 
 type StartInterviewResponse struct {
 	InterviewID int      `json:"interview_id"`
 	Question    Question `json:"question"`
+	Profile     *Profile `json:"profile,omitempty"` // the profile the questions were tailored to
 }
 
 type SubmitAnswerRequest struct {
 	QuestionID   int    `json:"question_id"`
 	ResponseText string `json:"response_text"`
+
+	// Code is an optional program in Language (go, python, javascript) or
+	// query (sql), checked and run separately from the text of the answer
+	Language string `json:"language,omitempty"`
+	Code     string `json:"code,omitempty"`
+
+	// SelectedOptions are the zero-based indexes of the options chosen in
+	// a single_choice or multi_choice question
+	SelectedOptions []int `json:"selected_options,omitempty"`
 }
 
 type SubmitAnswerResponse struct {
-	Feedback     string    `json:"feedback"`
-	Score        float64   `json:"score"`
-	NextQuestion *Question `json:"next_question,omitempty"`
-	Completed    bool      `json:"completed"`
+	Feedback         string         `json:"feedback"`
+	Score            *float64       `json:"score"` // nil when the answer is unscored
+	Status           string         `json:"status"`
+	Strengths        []string       `json:"strengths"`
+	Weaknesses       []string       `json:"weaknesses"`
+	Rubric           []RubricScore  `json:"rubric"`
+	KeyPointsCovered []string       `json:"key_points_covered"`
+	KeyPointsMissed  []string       `json:"key_points_missed"`
+	TestResults      []TestResult   `json:"test_results"`
+	Diagnostics      []Diagnostic   `json:"diagnostics"`
+	Diagrams         []Diagram      `json:"diagrams"`
+	QueryResult      *QueryResult   `json:"query_result,omitempty"`
+	NextQuestion     *Question      `json:"next_question,omitempty"`
+	Completed        bool           `json:"completed"`
+	FinalFeedback    *FinalFeedback `json:"final_feedback,omitempty"` // set once the interview is completed
+}
+
+type HintResponse struct {
+	Hint           Hint    `json:"hint"`
+	HintsUsed      int     `json:"hints_used"`
+	MaxHints       int     `json:"max_hints"`
+	PenaltyPerHint float64 `json:"penalty_per_hint"`
 }
 
 type InterviewResult struct {
-	Interview Interview  `json:"interview"`
-	Questions []Question `json:"questions"`
-	Responses []Response `json:"responses"`
+	Interview   Interview  `json:"interview"`
+	Questions   []Question `json:"questions"`
+	Responses   []Response `json:"responses"`
+	Hints       []Hint     `json:"hints"`
+	NeedsReview bool       `json:"needs_review"` // any response flagged for human review
+
+	// DifficultyTrajectory is the target difficulty of each top-level
+	// question in order
+	DifficultyTrajectory []string `json:"difficulty_trajectory"`
 }
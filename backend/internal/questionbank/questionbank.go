@@ -0,0 +1,59 @@
+// Package questionbank provides a small built-in set of interview
+// questions used when the AI provider is unavailable.
+package questionbank
+
+import (
+	_ "embed"
+	"encoding/json"
+	"math/rand"
+
+	"github.com/ai-interviewer/backend/internal/ai"
+)
+
+// PromptVersion is recorded as the prompt version of bank questions.
+const PromptVersion = "questionbank"
+
+//go:embed questions.json
+var questionsJSON []byte
+
+var questions []ai.GeneratedQuestion
+
+func init() {
+	if err := json.Unmarshal(questionsJSON, &questions); err != nil {
+		panic("questionbank: invalid questions.json: " + err.Error())
+	}
+	for i := range questions {
+		questions[i].PromptVersion = PromptVersion
+	}
+}
+
+// Pick returns count questions, preferring the requested difficulty and
+// topping up from the other levels if there are not enough. Questions whose
+// text is in exclude are never picked.
+func Pick(difficulty string, count int, exclude ...string) []ai.GeneratedQuestion {
+	skip := make(map[string]bool, len(exclude))
+	for _, text := range exclude {
+		skip[text] = true
+	}
+
+	var preferred, others []ai.GeneratedQuestion
+	for _, q := range questions {
+		if skip[q.Text] {
+			continue
+		}
+		if q.Difficulty == difficulty {
+			preferred = append(preferred, q)
+		} else {
+			others = append(others, q)
+		}
+	}
+
+	rand.Shuffle(len(preferred), func(i, j int) { preferred[i], preferred[j] = preferred[j], preferred[i] })
+	rand.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+
+	picked := append(preferred, others...)
+	if len(picked) > count {
+		picked = picked[:count]
+	}
+	return picked
+}
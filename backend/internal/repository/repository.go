@@ -2,10 +2,13 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ai-interviewer/backend/internal/models"
+	"github.com/go-sql-driver/mysql"
 )
 
 type Repository struct {
@@ -16,15 +19,20 @@ func New(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// dbtx is implemented by both *sql.DB and *sql.Tx, so writes can run
+// inside or outside a transaction.
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 // User operations
 func (r *Repository) CreateUser(name, email string) (*models.User, error) {
 	// Check if user exists
-	var existingUser models.User
-	err := r.db.QueryRow("SELECT id, name, email, created_at FROM users WHERE email = ?", email).
-		Scan(&existingUser.ID, &existingUser.Name, &existingUser.Email, &existingUser.CreatedAt)
+	existingUser, err := r.scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE email = ?", email))
 
 	if err == nil {
-		return &existingUser, nil
+		return existingUser, nil
 	}
 
 	if err != sql.ErrNoRows {
@@ -52,11 +60,45 @@ func (r *Repository) CreateUser(name, email string) (*models.User, error) {
 	return user, nil
 }
 
+func (r *Repository) GetUser(id int) (*models.User, error) {
+	return r.scanUser(r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id))
+}
+
+const userColumns = "id, name, email, profile, profile_updated_at, created_at"
+
+func (r *Repository) scanUser(row *sql.Row) (*models.User, error) {
+	var user models.User
+	var profile []byte
+	var profileUpdatedAt sql.NullTime
+	if err := row.Scan(&user.ID, &user.Name, &user.Email, &profile, &profileUpdatedAt, &user.CreatedAt); err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		user.Profile = &models.Profile{}
+		if err := json.Unmarshal(profile, user.Profile); err != nil {
+			return nil, err
+		}
+		user.Profile.UpdatedAt = profileUpdatedAt.Time
+	}
+	return &user, nil
+}
+
+// UpdateUserProfile replaces the resume profile stored on a user.
+func (r *Repository) UpdateUserProfile(userID int, profile *models.Profile) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec("UPDATE users SET profile = ?, profile_updated_at = ? WHERE id = ?", string(data), profile.UpdatedAt, userID)
+	return err
+}
+
 // Interview operations
-func (r *Repository) CreateInterview(userID int, position, difficulty string) (*models.Interview, error) {
+func (r *Repository) CreateInterview(interview models.Interview) (*models.Interview, error) {
 	result, err := r.db.Exec(
-		"INSERT INTO interviews (user_id, position, difficulty, status) VALUES (?, ?, ?, ?)",
-		userID, position, difficulty, "in_progress",
+		"INSERT INTO interviews (user_id, position, difficulty, status, prompt_version, max_follow_up_depth, adaptive, mode) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		interview.UserID, interview.Position, interview.Difficulty, "in_progress", nullString(interview.PromptVersion),
+		interview.MaxFollowUpDepth, interview.Adaptive, interview.Mode,
 	)
 	if err != nil {
 		return nil, err
@@ -67,29 +109,27 @@ func (r *Repository) CreateInterview(userID int, position, difficulty string) (*
 		return nil, err
 	}
 
-	interview := &models.Interview{
-		ID:         int(id),
-		UserID:     userID,
-		Position:   position,
-		Difficulty: difficulty,
-		Status:     "in_progress",
-		StartedAt:  time.Now(),
-	}
+	interview.ID = int(id)
+	interview.Status = "in_progress"
+	interview.StartedAt = time.Now()
 
-	return interview, nil
+	return &interview, nil
 }
 
-func (r *Repository) GetInterview(id int) (*models.Interview, error) {
+const interviewColumns = `id, user_id, position, difficulty, status, score, prompt_version, max_follow_up_depth, adaptive, mode,
+	feedback_summary, feedback_strengths, feedback_improvements, recommendation, feedback_prompt_version, started_at, completed_at`
+
+// scanInterview reads a row selected with interviewColumns.
+func scanInterview(row interface{ Scan(...interface{}) error }) (*models.Interview, error) {
 	var interview models.Interview
 	var score sql.NullFloat64
+	var promptVersion, summary, recommendation, feedbackVersion sql.NullString
+	var strengths, improvements []byte
 	var completedAt sql.NullTime
 
-	err := r.db.QueryRow(
-		"SELECT id, user_id, position, difficulty, status, score, started_at, completed_at FROM interviews WHERE id = ?",
-		id,
-	).Scan(&interview.ID, &interview.UserID, &interview.Position, &interview.Difficulty,
-		&interview.Status, &score, &interview.StartedAt, &completedAt)
-
+	err := row.Scan(&interview.ID, &interview.UserID, &interview.Position, &interview.Difficulty,
+		&interview.Status, &score, &promptVersion, &interview.MaxFollowUpDepth, &interview.Adaptive, &interview.Mode, &summary, &strengths, &improvements, &recommendation, &feedbackVersion,
+		&interview.StartedAt, &completedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +137,21 @@ func (r *Repository) GetInterview(id int) (*models.Interview, error) {
 	if score.Valid {
 		interview.Score = &score.Float64
 	}
+	interview.PromptVersion = promptVersion.String
+	if summary.Valid {
+		feedback := &models.FinalFeedback{
+			Summary:        summary.String,
+			Recommendation: recommendation.String,
+			PromptVersion:  feedbackVersion.String,
+		}
+		if feedback.Strengths, err = unmarshalStrings(strengths); err != nil {
+			return nil, err
+		}
+		if feedback.Improvements, err = unmarshalStrings(improvements); err != nil {
+			return nil, err
+		}
+		interview.FinalFeedback = feedback
+	}
 	if completedAt.Valid {
 		interview.CompletedAt = &completedAt.Time
 	}
@@ -104,6 +159,13 @@ func (r *Repository) GetInterview(id int) (*models.Interview, error) {
 	return &interview, nil
 }
 
+func (r *Repository) GetInterview(id int) (*models.Interview, error) {
+	return scanInterview(r.db.QueryRow(
+		"SELECT "+interviewColumns+" FROM interviews WHERE id = ?",
+		id,
+	))
+}
+
 func (r *Repository) UpdateInterviewStatus(id int, status string, score float64) error {
 	now := time.Now()
 	_, err := r.db.Exec(
@@ -113,9 +175,33 @@ func (r *Repository) UpdateInterviewStatus(id int, status string, score float64)
 	return err
 }
 
+func (r *Repository) UpdateInterviewScore(id int, score float64) error {
+	_, err := r.db.Exec("UPDATE interviews SET score = ? WHERE id = ?", score, id)
+	return err
+}
+
+// UpdateInterviewFeedback stores the final feedback of an interview.
+func (r *Repository) UpdateInterviewFeedback(id int, feedback models.FinalFeedback) error {
+	strengths, err := marshalStrings(feedback.Strengths)
+	if err != nil {
+		return err
+	}
+	improvements, err := marshalStrings(feedback.Improvements)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(
+		`UPDATE interviews SET feedback_summary = ?, feedback_strengths = ?, feedback_improvements = ?, recommendation = ?,
+			feedback_prompt_version = ? WHERE id = ?`,
+		feedback.Summary, strengths, improvements, feedback.Recommendation, nullString(feedback.PromptVersion), id,
+	)
+	return err
+}
+
 func (r *Repository) GetUserInterviews(userID int) ([]models.Interview, error) {
 	rows, err := r.db.Query(
-		"SELECT id, user_id, position, difficulty, status, score, started_at, completed_at FROM interviews WHERE user_id = ? ORDER BY started_at DESC",
+		"SELECT "+interviewColumns+" FROM interviews WHERE user_id = ? ORDER BY started_at DESC",
 		userID,
 	)
 	if err != nil {
@@ -125,74 +211,270 @@ func (r *Repository) GetUserInterviews(userID int) ([]models.Interview, error) {
 
 	var interviews []models.Interview
 	for rows.Next() {
-		var interview models.Interview
-		var score sql.NullFloat64
-		var completedAt sql.NullTime
-
-		err := rows.Scan(&interview.ID, &interview.UserID, &interview.Position, &interview.Difficulty,
-			&interview.Status, &score, &interview.StartedAt, &completedAt)
+		interview, err := scanInterview(rows)
 		if err != nil {
 			return nil, err
 		}
+		interviews = append(interviews, *interview)
+	}
 
-		if score.Valid {
-			interview.Score = &score.Float64
+	return interviews, nil
+}
+
+// Question operations
+func (r *Repository) CreateQuestion(question models.Question) (*models.Question, error) {
+	return createQuestion(r.db, question)
+}
+
+func createQuestion(db dbtx, question models.Question) (*models.Question, error) {
+	topics, err := marshalStrings(question.Topics)
+	if err != nil {
+		return nil, err
+	}
+	keyPoints, err := marshalStrings(question.KeyPoints)
+	if err != nil {
+		return nil, err
+	}
+	options, err := marshalStrings(question.Options)
+	if err != nil {
+		return nil, err
+	}
+	correctOptions, err := marshalInts(question.CorrectOptions)
+	if err != nil {
+		return nil, err
+	}
+	acceptedAnswers, err := marshalStrings(question.AcceptedAnswers)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.Exec(
+		`INSERT INTO questions (interview_id, question_text, question_type, topics, difficulty, target_difficulty, reference_answer,
+			key_points, options, correct_options, accepted_answers, answer_pattern, parent_question_id, depth, order_num)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		question.InterviewID, question.QuestionText, question.QuestionType, topics, nullString(question.Difficulty),
+		nullString(question.TargetDifficulty), nullString(question.ReferenceAnswer), keyPoints, options, correctOptions,
+		acceptedAnswers, nullString(question.AnswerPattern), question.ParentID, question.Depth, question.Order,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	question.ID = int(id)
+	question.CreatedAt = time.Now()
+	if question.Topics == nil {
+		question.Topics = []string{}
+	}
+
+	for i := range question.TestCases {
+		tc := &question.TestCases[i]
+		tc.QuestionID = question.ID
+		result, err := db.Exec(
+			"INSERT INTO test_cases (question_id, input, expected_output) VALUES (?, ?, ?)",
+			tc.QuestionID, tc.Input, tc.ExpectedOutput,
+		)
+		if err != nil {
+			return nil, err
 		}
-		if completedAt.Valid {
-			interview.CompletedAt = &completedAt.Time
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
 		}
+		tc.ID = int(id)
+	}
 
-		interviews = append(interviews, interview)
+	if question.Dataset != nil {
+		question.Dataset.QuestionID = question.ID
+		if err := saveDataset(db, question.Dataset); err != nil {
+			return nil, err
+		}
 	}
 
-	return interviews, nil
+	return &question, nil
 }
 
-// Question operations
-func (r *Repository) CreateQuestion(interviewID int, questionText, questionType string, order int) (*models.Question, error) {
-	result, err := r.db.Exec(
-		"INSERT INTO questions (interview_id, question_text, question_type, order_num) VALUES (?, ?, ?, ?)",
-		interviewID, questionText, questionType, order,
+func saveDataset(db dbtx, d *models.Dataset) error {
+	columns, err := json.Marshal(d.ExpectedColumns)
+	if err != nil {
+		return err
+	}
+	rows, err := json.Marshal(d.ExpectedRows)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		`INSERT INTO sql_datasets (question_id, schema_sql, seed_sql, solution, ordered, expected_columns, expected_rows)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		d.QuestionID, d.Schema, d.Seed, d.Solution, d.Ordered, string(columns), string(rows),
 	)
+	return err
+}
+
+// getDataset returns the dataset of a SQL question, or nil if it has none.
+func (r *Repository) getDataset(questionID int) (*models.Dataset, error) {
+	d := models.Dataset{QuestionID: questionID}
+	var columns, rows []byte
+	err := r.db.QueryRow(
+		"SELECT schema_sql, seed_sql, solution, ordered, expected_columns, expected_rows FROM sql_datasets WHERE question_id = ?",
+		questionID,
+	).Scan(&d.Schema, &d.Seed, &d.Solution, &d.Ordered, &columns, &rows)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(columns, &d.ExpectedColumns); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rows, &d.ExpectedRows); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
 
-	id, err := result.LastInsertId()
+const questionColumns = `id, interview_id, question_text, question_type, topics, difficulty, target_difficulty, reference_answer, key_points,
+	options, correct_options, accepted_answers, answer_pattern, parent_question_id, depth, order_num, created_at`
+
+// scanQuestion reads a row selected with questionColumns.
+func scanQuestion(row interface{ Scan(...interface{}) error }) (*models.Question, error) {
+	var question models.Question
+	var topics, keyPoints, options, correctOptions, acceptedAnswers []byte
+	var difficulty, targetDifficulty, referenceAnswer, answerPattern sql.NullString
+	var parentID sql.NullInt64
+	err := row.Scan(&question.ID, &question.InterviewID, &question.QuestionText, &question.QuestionType,
+		&topics, &difficulty, &targetDifficulty, &referenceAnswer, &keyPoints, &options, &correctOptions, &acceptedAnswers, &answerPattern,
+		&parentID, &question.Depth, &question.Order, &question.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		question.ParentID = &id
+	}
+	question.Difficulty = difficulty.String
+	question.TargetDifficulty = targetDifficulty.String
+	question.ReferenceAnswer = referenceAnswer.String
+	question.AnswerPattern = answerPattern.String
+	if question.Topics, err = unmarshalStrings(topics); err != nil {
+		return nil, err
+	}
+	if question.KeyPoints, err = unmarshalStrings(keyPoints); err != nil {
+		return nil, err
+	}
+	if question.Options, err = unmarshalStrings(options); err != nil {
+		return nil, err
+	}
+	if question.CorrectOptions, err = unmarshalInts(correctOptions); err != nil {
+		return nil, err
+	}
+	if question.AcceptedAnswers, err = unmarshalStrings(acceptedAnswers); err != nil {
+		return nil, err
+	}
+
+	return &question, nil
+}
+
+// ErrFollowUpExists is returned by CreateFollowUp when the parent question
+// already has a follow-up.
+var ErrFollowUpExists = errors.New("question already has a follow-up")
+
+// CreateFollowUp inserts a follow-up at its Order, moving every question of
+// the interview from that position on one place down. Both happen in one
+// transaction, which changes nothing if the parent question already has a
+// follow-up.
+func (r *Repository) CreateFollowUp(question models.Question) (*models.Question, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	question := &models.Question{
-		ID:           int(id),
-		InterviewID:  interviewID,
-		QuestionText: questionText,
-		QuestionType: questionType,
-		Order:        order,
-		CreatedAt:    time.Now(),
+	// Lock the parent question, like SELECT ... FOR UPDATE but in a form
+	// SQLite accepts too, so a concurrent submission waits here and then
+	// sees the follow-up this one created
+	if _, err := tx.Exec("UPDATE questions SET depth = depth WHERE id = ?", question.ParentID); err != nil {
+		return nil, err
+	}
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM questions WHERE parent_question_id = ?)", question.ParentID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrFollowUpExists
 	}
 
-	return question, nil
+	_, err = tx.Exec(
+		"UPDATE questions SET order_num = order_num + 1 WHERE interview_id = ? AND order_num >= ?",
+		question.InterviewID, question.Order,
+	)
+	if err != nil {
+		return nil, err
+	}
+	created, err := createQuestion(tx, question)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
+// GetQuestion returns a question together with its test cases and
+// dataset.
 func (r *Repository) GetQuestion(id int) (*models.Question, error) {
-	var question models.Question
-	err := r.db.QueryRow(
-		"SELECT id, interview_id, question_text, question_type, order_num, created_at FROM questions WHERE id = ?",
+	question, err := scanQuestion(r.db.QueryRow(
+		"SELECT "+questionColumns+" FROM questions WHERE id = ?",
 		id,
-	).Scan(&question.ID, &question.InterviewID, &question.QuestionText, &question.QuestionType,
-		&question.Order, &question.CreatedAt)
+	))
+	if err != nil {
+		return nil, err
+	}
 
+	if question.TestCases, err = r.getTestCases(question.ID); err != nil {
+		return nil, err
+	}
+	if question.QuestionType == "sql" {
+		if question.Dataset, err = r.getDataset(question.ID); err != nil {
+			return nil, err
+		}
+	}
+	return question, nil
+}
+
+func (r *Repository) getTestCases(questionID int) ([]models.TestCase, error) {
+	rows, err := r.db.Query(
+		"SELECT id, question_id, input, expected_output FROM test_cases WHERE question_id = ? ORDER BY id",
+		questionID,
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &question, nil
+	var testCases []models.TestCase
+	for rows.Next() {
+		var tc models.TestCase
+		if err := rows.Scan(&tc.ID, &tc.QuestionID, &tc.Input, &tc.ExpectedOutput); err != nil {
+			return nil, err
+		}
+		testCases = append(testCases, tc)
+	}
+
+	return testCases, rows.Err()
 }
 
 func (r *Repository) GetInterviewQuestions(interviewID int) ([]models.Question, error) {
 	rows, err := r.db.Query(
-		"SELECT id, interview_id, question_text, question_type, order_num, created_at FROM questions WHERE interview_id = ? ORDER BY order_num",
+		"SELECT "+questionColumns+" FROM questions WHERE interview_id = ? ORDER BY order_num",
 		interviewID,
 	)
 	if err != nil {
@@ -202,24 +484,42 @@ func (r *Repository) GetInterviewQuestions(interviewID int) ([]models.Question,
 
 	var questions []models.Question
 	for rows.Next() {
-		var question models.Question
-		err := rows.Scan(&question.ID, &question.InterviewID, &question.QuestionText,
-			&question.QuestionType, &question.Order, &question.CreatedAt)
+		question, err := scanQuestion(rows)
 		if err != nil {
 			return nil, err
 		}
-		questions = append(questions, question)
+		questions = append(questions, *question)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Candidates need the schema of SQL questions to answer them
+	for i := range questions {
+		if questions[i].QuestionType != "sql" {
+			continue
+		}
+		if questions[i].Dataset, err = r.getDataset(questions[i].ID); err != nil {
+			return nil, err
+		}
 	}
 
 	return questions, nil
 }
 
-// Response operations
-func (r *Repository) CreateResponse(questionID int, responseText, feedback string, score float64) (*models.Response, error) {
+// ErrHintExists is returned by CreateHint when the question already has a
+// hint of that level, given to a concurrent request.
+var ErrHintExists = errors.New("hint already given")
+
+// Hint operations
+func (r *Repository) CreateHint(hint models.Hint) (*models.Hint, error) {
 	result, err := r.db.Exec(
-		"INSERT INTO responses (question_id, response_text, feedback, score) VALUES (?, ?, ?, ?)",
-		questionID, responseText, feedback, score,
+		"INSERT INTO hints (question_id, level, hint_text, prompt_version) VALUES (?, ?, ?, ?)",
+		hint.QuestionID, hint.Level, hint.Text, nullString(hint.PromptVersion),
 	)
+	if isDuplicateKey(err) {
+		return nil, ErrHintExists
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -229,21 +529,16 @@ func (r *Repository) CreateResponse(questionID int, responseText, feedback strin
 		return nil, err
 	}
 
-	response := &models.Response{
-		ID:           int(id),
-		QuestionID:   questionID,
-		ResponseText: responseText,
-		Feedback:     feedback,
-		Score:        &score,
-		CreatedAt:    time.Now(),
-	}
+	hint.ID = int(id)
+	hint.CreatedAt = time.Now()
 
-	return response, nil
+	return &hint, nil
 }
 
-func (r *Repository) GetQuestionResponses(questionID int) ([]models.Response, error) {
+// GetQuestionHints returns the hints given for a question, weakest first.
+func (r *Repository) GetQuestionHints(questionID int) ([]models.Hint, error) {
 	rows, err := r.db.Query(
-		"SELECT id, question_id, response_text, feedback, score, created_at FROM responses WHERE question_id = ?",
+		"SELECT id, question_id, level, hint_text, prompt_version, created_at FROM hints WHERE question_id = ? ORDER BY level",
 		questionID,
 	)
 	if err != nil {
@@ -251,22 +546,542 @@ func (r *Repository) GetQuestionResponses(questionID int) ([]models.Response, er
 	}
 	defer rows.Close()
 
+	hints := []models.Hint{}
+	for rows.Next() {
+		var hint models.Hint
+		var promptVersion sql.NullString
+		if err := rows.Scan(&hint.ID, &hint.QuestionID, &hint.Level, &hint.Text, &promptVersion, &hint.CreatedAt); err != nil {
+			return nil, err
+		}
+		hint.PromptVersion = promptVersion.String
+		hints = append(hints, hint)
+	}
+
+	return hints, rows.Err()
+}
+
+// Model answer operations
+func (r *Repository) CreateModelAnswer(answer models.ModelAnswer) (*models.ModelAnswer, error) {
+	result, err := r.db.Exec(
+		"INSERT INTO model_answers (response_id, model_answer, explanation, prompt_version) VALUES (?, ?, ?, ?)",
+		answer.ResponseID, answer.Answer, answer.Explanation, nullString(answer.PromptVersion),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	answer.ID = int(id)
+	answer.CreatedAt = time.Now()
+
+	return &answer, nil
+}
+
+// GetModelAnswer returns the model answer revealed for a response, or
+// sql.ErrNoRows if none was generated yet.
+func (r *Repository) GetModelAnswer(responseID int) (*models.ModelAnswer, error) {
+	var answer models.ModelAnswer
+	var promptVersion sql.NullString
+	err := r.db.QueryRow(
+		"SELECT id, response_id, model_answer, explanation, prompt_version, created_at FROM model_answers WHERE response_id = ?",
+		responseID,
+	).Scan(&answer.ID, &answer.ResponseID, &answer.Answer, &answer.Explanation, &promptVersion, &answer.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	answer.PromptVersion = promptVersion.String
+	return &answer, nil
+}
+
+// ErrAlreadyAnswered is returned by CreateResponse when the question
+// already has a response, stored by an earlier or concurrent submission.
+var ErrAlreadyAnswered = errors.New("question already answered")
+
+// Response operations
+func (r *Repository) CreateResponse(response models.Response) (*models.Response, error) {
+	strengths, err := marshalStrings(response.Strengths)
+	if err != nil {
+		return nil, err
+	}
+	weaknesses, err := marshalStrings(response.Weaknesses)
+	if err != nil {
+		return nil, err
+	}
+	covered, err := marshalStrings(response.KeyPointsCovered)
+	if err != nil {
+		return nil, err
+	}
+	missed, err := marshalStrings(response.KeyPointsMissed)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := marshalInts(response.SelectedOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the question, as CreateFollowUp does, so a concurrent submission
+	// waits here and then sees the response this one stored
+	if _, err := tx.Exec("UPDATE questions SET depth = depth WHERE id = ?", response.QuestionID); err != nil {
+		return nil, err
+	}
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM responses WHERE question_id = ?)", response.QuestionID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrAlreadyAnswered
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO responses (question_id, response_text, language, code, selected_options, feedback, score, status, strengths, weaknesses,
+			key_points_covered, key_points_missed, score_spread, sample_count, failed_samples, needs_review, review_reason, hint_penalty,
+			injection_flagged, prompt_version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		response.QuestionID, response.ResponseText, nullString(response.Language), nullString(response.Code), selected, response.Feedback,
+		response.Score, response.Status, strengths, weaknesses,
+		covered, missed, response.ScoreSpread, nullInt(response.Samples), nullInt(response.FailedSamples), response.NeedsReview,
+		nullString(response.ReviewReason), response.HintPenalty, response.InjectionFlagged, nullString(response.PromptVersion),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	response.ID = int(id)
+	response.CreatedAt = time.Now()
+
+	if err := r.saveRubric(response.ID, response.Rubric); err != nil {
+		return nil, err
+	}
+	if response.Rubric == nil {
+		response.Rubric = []models.RubricScore{}
+	}
+	if err := r.saveTestResults(response.ID, response.TestResults); err != nil {
+		return nil, err
+	}
+	if response.TestResults == nil {
+		response.TestResults = []models.TestResult{}
+	}
+	if err := r.saveDiagnostics(response.ID, response.Diagnostics); err != nil {
+		return nil, err
+	}
+	if response.Diagnostics == nil {
+		response.Diagnostics = []models.Diagnostic{}
+	}
+	if err := r.saveDiagrams(response.ID, response.Diagrams); err != nil {
+		return nil, err
+	}
+	if response.Diagrams == nil {
+		response.Diagrams = []models.Diagram{}
+	}
+	if response.QueryResult != nil {
+		if err := r.saveQueryResult(response.ID, response.QueryResult); err != nil {
+			return nil, err
+		}
+	}
+
+	return &response, nil
+}
+
+func (r *Repository) UpdateResponseEvaluation(response models.Response) error {
+	strengths, err := marshalStrings(response.Strengths)
+	if err != nil {
+		return err
+	}
+	weaknesses, err := marshalStrings(response.Weaknesses)
+	if err != nil {
+		return err
+	}
+	covered, err := marshalStrings(response.KeyPointsCovered)
+	if err != nil {
+		return err
+	}
+	missed, err := marshalStrings(response.KeyPointsMissed)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(
+		`UPDATE responses SET feedback = ?, score = ?, status = ?, strengths = ?, weaknesses = ?, key_points_covered = ?, key_points_missed = ?,
+			score_spread = ?, sample_count = ?, failed_samples = ?, needs_review = ?, review_reason = ?, hint_penalty = ?,
+			injection_flagged = ?, prompt_version = ? WHERE id = ?`,
+		response.Feedback, response.Score, response.Status, strengths, weaknesses, covered, missed,
+		response.ScoreSpread, nullInt(response.Samples), nullInt(response.FailedSamples), response.NeedsReview, nullString(response.ReviewReason),
+		response.HintPenalty, response.InjectionFlagged, nullString(response.PromptVersion), response.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec("DELETE FROM response_scores WHERE response_id = ?", response.ID); err != nil {
+		return err
+	}
+	return r.saveRubric(response.ID, response.Rubric)
+}
+
+func (r *Repository) saveRubric(responseID int, rubric []models.RubricScore) error {
+	for _, score := range rubric {
+		_, err := r.db.Exec(
+			"INSERT INTO response_scores (response_id, dimension, score, justification) VALUES (?, ?, ?, ?)",
+			responseID, score.Dimension, score.Score, score.Justification,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Repository) getRubric(responseID int) ([]models.RubricScore, error) {
+	rows, err := r.db.Query(
+		"SELECT dimension, score, justification FROM response_scores WHERE response_id = ? ORDER BY id",
+		responseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rubric := []models.RubricScore{}
+	for rows.Next() {
+		var score models.RubricScore
+		var justification sql.NullString
+		if err := rows.Scan(&score.Dimension, &score.Score, &justification); err != nil {
+			return nil, err
+		}
+		score.Justification = justification.String
+		rubric = append(rubric, score)
+	}
+
+	return rubric, rows.Err()
+}
+
+func (r *Repository) saveTestResults(responseID int, results []models.TestResult) error {
+	for i := range results {
+		results[i].ResponseID = responseID
+		res := results[i]
+		result, err := r.db.Exec(
+			"INSERT INTO test_results (response_id, test_case_id, passed, output, error, duration_ms) VALUES (?, ?, ?, ?, ?, ?)",
+			res.ResponseID, res.TestCaseID, res.Passed, res.Output, nullString(res.Error), res.DurationMS,
+		)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		results[i].ID = int(id)
+	}
+	return nil
+}
+
+// getTestResults returns the test results of a response together with the
+// test cases they ran.
+func (r *Repository) getTestResults(responseID int) ([]models.TestResult, error) {
+	rows, err := r.db.Query(
+		`SELECT tr.id, tr.response_id, tr.test_case_id, tc.input, tc.expected_output, tr.output, tr.passed, tr.error, tr.duration_ms
+			FROM test_results tr JOIN test_cases tc ON tc.id = tr.test_case_id WHERE tr.response_id = ? ORDER BY tc.id`,
+		responseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []models.TestResult{}
+	for rows.Next() {
+		var res models.TestResult
+		var output, errText sql.NullString
+		err := rows.Scan(&res.ID, &res.ResponseID, &res.TestCaseID, &res.Input, &res.ExpectedOutput,
+			&output, &res.Passed, &errText, &res.DurationMS)
+		if err != nil {
+			return nil, err
+		}
+		res.Output = output.String
+		res.Error = errText.String
+		results = append(results, res)
+	}
+
+	return results, rows.Err()
+}
+
+func (r *Repository) saveDiagnostics(responseID int, diagnostics []models.Diagnostic) error {
+	for i := range diagnostics {
+		diagnostics[i].ResponseID = responseID
+		d := diagnostics[i]
+		result, err := r.db.Exec(
+			"INSERT INTO code_diagnostics (response_id, tool, severity, line, col, message) VALUES (?, ?, ?, ?, ?, ?)",
+			d.ResponseID, d.Tool, d.Severity, nullInt(d.Line), nullInt(d.Column), d.Message,
+		)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		diagnostics[i].ID = int(id)
+	}
+	return nil
+}
+
+func (r *Repository) getDiagnostics(responseID int) ([]models.Diagnostic, error) {
+	rows, err := r.db.Query(
+		"SELECT id, response_id, tool, severity, line, col, message FROM code_diagnostics WHERE response_id = ? ORDER BY id",
+		responseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	diagnostics := []models.Diagnostic{}
+	for rows.Next() {
+		var d models.Diagnostic
+		var line, col sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.ResponseID, &d.Tool, &d.Severity, &line, &col, &d.Message); err != nil {
+			return nil, err
+		}
+		d.Line = int(line.Int64)
+		d.Column = int(col.Int64)
+		diagnostics = append(diagnostics, d)
+	}
+
+	return diagnostics, rows.Err()
+}
+
+func (r *Repository) saveDiagrams(responseID int, diagrams []models.Diagram) error {
+	for i := range diagrams {
+		diagrams[i].ResponseID = responseID
+		d := diagrams[i]
+		components, err := json.Marshal(d.Components)
+		if err != nil {
+			return err
+		}
+		edges, err := json.Marshal(d.Edges)
+		if err != nil {
+			return err
+		}
+		result, err := r.db.Exec(
+			"INSERT INTO diagrams (response_id, format, components, edges, error) VALUES (?, ?, ?, ?, ?)",
+			d.ResponseID, d.Format, string(components), string(edges), nullString(d.Error),
+		)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		diagrams[i].ID = int(id)
+	}
+	return nil
+}
+
+func (r *Repository) getDiagrams(responseID int) ([]models.Diagram, error) {
+	rows, err := r.db.Query(
+		"SELECT id, response_id, format, components, edges, error FROM diagrams WHERE response_id = ? ORDER BY id",
+		responseID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	diagrams := []models.Diagram{}
+	for rows.Next() {
+		var d models.Diagram
+		var components, edges []byte
+		var errText sql.NullString
+		if err := rows.Scan(&d.ID, &d.ResponseID, &d.Format, &components, &edges, &errText); err != nil {
+			return nil, err
+		}
+		d.Components, d.Edges = []models.DiagramComponent{}, []models.DiagramEdge{}
+		if len(components) > 0 {
+			if err := json.Unmarshal(components, &d.Components); err != nil {
+				return nil, err
+			}
+		}
+		if len(edges) > 0 {
+			if err := json.Unmarshal(edges, &d.Edges); err != nil {
+				return nil, err
+			}
+		}
+		d.Error = errText.String
+		diagrams = append(diagrams, d)
+	}
+
+	return diagrams, rows.Err()
+}
+
+func (r *Repository) saveQueryResult(responseID int, q *models.QueryResult) error {
+	q.ResponseID = responseID
+	columns, err := json.Marshal(q.Columns)
+	if err != nil {
+		return err
+	}
+	rows, err := json.Marshal(q.Rows)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.Exec(
+		`INSERT INTO query_results (response_id, score, matched_rows, expected_rows, returned_rows, result_columns, result_rows, reason, error)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		q.ResponseID, q.Score, q.MatchedRows, q.ExpectedRows, q.ReturnedRows, string(columns), string(rows),
+		nullString(q.Reason), nullString(q.Error),
+	)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	q.ID = int(id)
+	return nil
+}
+
+// getQueryResult returns the query result of a response, or nil if it has
+// none.
+func (r *Repository) getQueryResult(responseID int) (*models.QueryResult, error) {
+	var q models.QueryResult
+	var columns, rows []byte
+	var reason, errText sql.NullString
+	err := r.db.QueryRow(
+		`SELECT id, response_id, score, matched_rows, expected_rows, returned_rows, result_columns, result_rows, reason, error
+			FROM query_results WHERE response_id = ?`,
+		responseID,
+	).Scan(&q.ID, &q.ResponseID, &q.Score, &q.MatchedRows, &q.ExpectedRows, &q.ReturnedRows, &columns, &rows, &reason, &errText)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	q.Columns, q.Rows = []string{}, [][]string{}
+	if len(columns) > 0 {
+		if err := json.Unmarshal(columns, &q.Columns); err != nil {
+			return nil, err
+		}
+	}
+	if len(rows) > 0 {
+		if err := json.Unmarshal(rows, &q.Rows); err != nil {
+			return nil, err
+		}
+	}
+	q.Reason = reason.String
+	q.Error = errText.String
+	return &q, nil
+}
+
+const responseColumns = `id, question_id, response_text, language, code, selected_options, feedback, score, status, strengths, weaknesses, key_points_covered, key_points_missed,
+	score_spread, sample_count, failed_samples, needs_review, review_reason, hint_penalty, injection_flagged, prompt_version, created_at`
+
+func (r *Repository) GetQuestionResponses(questionID int) ([]models.Response, error) {
+	return r.queryResponses("SELECT "+responseColumns+" FROM responses WHERE question_id = ?", questionID)
+}
+
+// GetPendingResponses returns up to limit answers still waiting for an AI
+// evaluation, oldest first.
+func (r *Repository) GetPendingResponses(limit int) ([]models.Response, error) {
+	return r.queryResponses("SELECT "+responseColumns+" FROM responses WHERE status = 'pending' ORDER BY created_at LIMIT ?", limit)
+}
+
+func (r *Repository) queryResponses(query string, args ...interface{}) ([]models.Response, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
 	var responses []models.Response
 	for rows.Next() {
 		var response models.Response
+		var feedback sql.NullString
 		var score sql.NullFloat64
-		err := rows.Scan(&response.ID, &response.QuestionID, &response.ResponseText,
-			&response.Feedback, &score, &response.CreatedAt)
+		var selected, strengths, weaknesses, covered, missed []byte
+		var spread sql.NullFloat64
+		var samples, failed sql.NullInt64
+		var language, code, reviewReason, promptVersion sql.NullString
+		err := rows.Scan(&response.ID, &response.QuestionID, &response.ResponseText, &language, &code, &selected,
+			&feedback, &score, &response.Status, &strengths, &weaknesses, &covered, &missed,
+			&spread, &samples, &failed, &response.NeedsReview, &reviewReason, &response.HintPenalty, &response.InjectionFlagged, &promptVersion, &response.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
 
+		response.Language = language.String
+		response.Code = code.String
+		response.Feedback = feedback.String
+		response.Samples = int(samples.Int64)
+		response.FailedSamples = int(failed.Int64)
+		response.ReviewReason = reviewReason.String
+		response.PromptVersion = promptVersion.String
 		if score.Valid {
 			response.Score = &score.Float64
 		}
+		if spread.Valid {
+			response.ScoreSpread = &spread.Float64
+		}
+		if response.SelectedOptions, err = unmarshalInts(selected); err != nil {
+			return nil, err
+		}
+		if response.Strengths, err = unmarshalStrings(strengths); err != nil {
+			return nil, err
+		}
+		if response.Weaknesses, err = unmarshalStrings(weaknesses); err != nil {
+			return nil, err
+		}
+		if response.KeyPointsCovered, err = unmarshalStrings(covered); err != nil {
+			return nil, err
+		}
+		if response.KeyPointsMissed, err = unmarshalStrings(missed); err != nil {
+			return nil, err
+		}
 
 		responses = append(responses, response)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range responses {
+		if responses[i].Rubric, err = r.getRubric(responses[i].ID); err != nil {
+			return nil, err
+		}
+		if responses[i].TestResults, err = r.getTestResults(responses[i].ID); err != nil {
+			return nil, err
+		}
+		if responses[i].Diagnostics, err = r.getDiagnostics(responses[i].ID); err != nil {
+			return nil, err
+		}
+		if responses[i].Diagrams, err = r.getDiagrams(responses[i].ID); err != nil {
+			return nil, err
+		}
+		if responses[i].QueryResult, err = r.getQueryResult(responses[i].ID); err != nil {
+			return nil, err
+		}
+	}
 
 	return responses, nil
 }
@@ -283,12 +1098,19 @@ func (r *Repository) GetInterviewResult(interviewID int) (*models.InterviewResul
 	}
 
 	var responses []models.Response
+	hints := []models.Hint{}
 	for _, q := range questions {
 		qResponses, err := r.GetQuestionResponses(q.ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get responses: %w", err)
 		}
 		responses = append(responses, qResponses...)
+
+		qHints, err := r.GetQuestionHints(q.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hints: %w", err)
+		}
+		hints = append(hints, qHints...)
 	}
 
 	// Initialize empty array if no responses
@@ -296,9 +1118,95 @@ func (r *Repository) GetInterviewResult(interviewID int) (*models.InterviewResul
 		responses = []models.Response{}
 	}
 
+	needsReview := false
+	for _, resp := range responses {
+		needsReview = needsReview || resp.NeedsReview
+	}
+
+	// Follow-ups probe their parent and are not part of the trajectory
+	trajectory := []string{}
+	for _, q := range questions {
+		if q.Depth > 0 {
+			continue
+		}
+		if q.TargetDifficulty != "" {
+			trajectory = append(trajectory, q.TargetDifficulty)
+		} else {
+			trajectory = append(trajectory, interview.Difficulty)
+		}
+	}
+
 	return &models.InterviewResult{
-		Interview: *interview,
-		Questions: questions,
-		Responses: responses,
+		Interview:            *interview,
+		Questions:            questions,
+		Responses:            responses,
+		Hints:                hints,
+		NeedsReview:          needsReview,
+		DifficultyTrajectory: trajectory,
 	}, nil
 }
+
+// marshalStrings encodes a string list for a JSON column, storing NULL for
+// an empty list.
+func marshalStrings(values []string) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func unmarshalStrings(data []byte) ([]string, error) {
+	values := []string{}
+	if len(data) == 0 {
+		return values, nil
+	}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// marshalInts encodes an int list for a JSON column, storing NULL for an
+// empty list.
+func marshalInts(values []int) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// unmarshalInts decodes a JSON int list column, returning nil for NULL.
+func unmarshalInts(data []byte) ([]int, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var values []int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// nullInt stores zero as NULL.
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
+// nullString stores an empty string as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// isDuplicateKey reports whether err is MySQL's duplicate-key error.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
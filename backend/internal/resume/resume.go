@@ -0,0 +1,160 @@
+// Package resume extracts the plain text of a resume uploaded as text,
+// Markdown or PDF.
+package resume
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// MaxBytes caps the size of an uploaded resume.
+const MaxBytes = 2 << 20
+
+// MaxTextLength caps the characters of resume text kept for the prompt;
+// longer resumes are cut off.
+const MaxTextLength = 20000
+
+// Formats lists the accepted resume formats.
+var Formats = []string{"text", "markdown", "pdf"}
+
+// ErrInvalidResume is returned when a resume cannot be read.
+var ErrInvalidResume = errors.New("invalid resume")
+
+// pdfTimeout bounds reading the text of a PDF. It is a variable so tests
+// can shorten it.
+var pdfTimeout = 10 * time.Second
+
+// pdfSlots bounds the PDFs parsed at once. The parser cannot be stopped,
+// so a parse that outlives its deadline keeps its slot until it returns
+// and crafted files cannot pile up.
+var pdfSlots = make(chan struct{}, 4)
+
+// Text returns the plain text of a resume in format, with runs of blank
+// lines collapsed and cut to MaxTextLength characters. A PDF that takes
+// longer than pdfTimeout or ctx allows to read is rejected.
+func Text(ctx context.Context, format string, data []byte) (string, error) {
+	if len(data) > MaxBytes {
+		return "", fmt.Errorf("%w: larger than %d bytes", ErrInvalidResume, MaxBytes)
+	}
+
+	var text string
+	switch format {
+	case "text", "markdown":
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("%w: not valid UTF-8 text", ErrInvalidResume)
+		}
+		text = string(data)
+	case "pdf":
+		var err error
+		text, err = pdfTextContext(ctx, data)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", fmt.Errorf("%w: reading the PDF took too long", ErrInvalidResume)
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidResume, err)
+		}
+	default:
+		return "", fmt.Errorf("%w: format must be one of %s", ErrInvalidResume, strings.Join(Formats, ", "))
+	}
+
+	text = normalize(text)
+	if text == "" {
+		return "", fmt.Errorf("%w: no text found", ErrInvalidResume)
+	}
+	if utf8.RuneCountInString(text) > MaxTextLength {
+		text = string([]rune(text)[:MaxTextLength])
+	}
+	return text, nil
+}
+
+// pdfTextContext runs pdfText in one of pdfSlots, giving up when ctx is
+// done or pdfTimeout passes.
+func pdfTextContext(ctx context.Context, data []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, pdfTimeout)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	select {
+	case pdfSlots <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	type result struct {
+		text string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-pdfSlots }()
+		text, err := pdfText(data)
+		done <- result{text, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.text, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// pdfText extracts the text of every page of a PDF. The parser panics on
+// some malformed files, which are reported as errors instead.
+func pdfText(data []byte) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF")
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	plain, err := reader.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	out, err := io.ReadAll(plain)
+	if err != nil {
+		return "", err
+	}
+	if !utf8.Valid(out) {
+		out = bytes.ToValidUTF8(out, nil)
+	}
+	return string(out), nil
+}
+
+// normalize strips trailing spaces from every line and keeps at most one
+// blank line in a row.
+func normalize(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	kept := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			if !blank && len(kept) > 0 {
+				kept = append(kept, "")
+			}
+			blank = true
+			continue
+		}
+		kept = append(kept, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
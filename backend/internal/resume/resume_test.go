@@ -0,0 +1,139 @@
+package resume
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// onePagePDF builds a PDF whose only page shows text.
+func onePagePDF(text string) []byte {
+	content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func TestText(t *testing.T) {
+	long := strings.Repeat("é", MaxTextLength+10)
+
+	tests := []struct {
+		name    string
+		format  string
+		data    []byte
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "text",
+			format: "text",
+			data:   []byte("  Ada Lovelace  \r\n\r\n\r\n\nGo, SQL\t\n\n"),
+			want:   "Ada Lovelace\n\nGo, SQL",
+		},
+		{
+			name:   "markdown",
+			format: "markdown",
+			data:   []byte("# Ada\n\n- Go\n- SQL\n"),
+			want:   "# Ada\n\n- Go\n- SQL",
+		},
+		{
+			name:   "pdf",
+			format: "pdf",
+			data:   onePagePDF("Ada Lovelace, Go developer"),
+			want:   "Ada Lovelace, Go developer",
+		},
+		{
+			name:   "cut to MaxTextLength characters",
+			format: "text",
+			data:   []byte(long),
+			want:   long[:2*MaxTextLength],
+		},
+		{
+			name:    "unknown format",
+			format:  "docx",
+			data:    []byte("Ada"),
+			wantErr: "format must be one of text, markdown, pdf",
+		},
+		{
+			name:    "invalid UTF-8",
+			format:  "text",
+			data:    []byte{0xff, 0xfe},
+			wantErr: "not valid UTF-8",
+		},
+		{
+			name:    "blank",
+			format:  "markdown",
+			data:    []byte(" \n\n \t\n"),
+			wantErr: "no text found",
+		},
+		{
+			name:    "too large",
+			format:  "text",
+			data:    bytes.Repeat([]byte("a"), MaxBytes+1),
+			wantErr: "larger than",
+		},
+		{
+			name:    "malformed pdf",
+			format:  "pdf",
+			data:    []byte("%PDF-1.4\nnot really a pdf"),
+			wantErr: "invalid resume",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Text(context.Background(), tt.format, tt.data)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidResume) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want an invalid resume error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextPDFDeadline(t *testing.T) {
+	pdf := onePagePDF("Ada Lovelace")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Text(ctx, "pdf", pdf); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled: err = %v, want context.Canceled", err)
+	}
+
+	defer func(d time.Duration) { pdfTimeout = d }(pdfTimeout)
+	pdfTimeout = 0
+	if _, err := Text(context.Background(), "pdf", pdf); !errors.Is(err, ErrInvalidResume) || !strings.Contains(err.Error(), "took too long") {
+		t.Errorf("timed out: err = %v, want an invalid resume error saying it took too long", err)
+	}
+}
@@ -0,0 +1,196 @@
+package sandbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnalysisLanguages lists the languages Analyze can check. It is a
+// superset of Languages.
+var AnalysisLanguages = []string{"go", "python", "javascript"}
+
+// Limits of the formatters, linters and parsers. go vet type-checks the
+// code, which first compiles the standard library packages it imports into
+// the run's empty build cache; the others only parse. The tools run on the
+// Go runtime or V8, which reserve a lot of address space up front.
+const (
+	analysisTimeout  = 10 * time.Second
+	vetTimeout       = 30 * time.Second
+	analysisMemoryMB = 1024
+)
+
+// Diagnostic severities. Errors mean the code does not parse or compile.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is one finding of a formatter, linter or parser.
+type Diagnostic struct {
+	Tool     string `json:"tool"`
+	Severity string `json:"severity"`
+	Line     int    `json:"line"` // 0 when the finding is about the whole file
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+// Analyze runs the static checks for language over code: gofmt and go vet
+// for Go, a parser check for Python and JavaScript. The tools run under the
+// same isolation as candidate programs, at most maxConcurrentTools builds
+// and analyses at a time. Clean code yields no diagnostics.
+func (r *Runner) Analyze(ctx context.Context, language, code string) ([]Diagnostic, error) {
+	release, err := r.acquireTool(ctx, analysisTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	dir, err := os.MkdirTemp("", "sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	switch language {
+	case "go":
+		return r.analyzeGo(ctx, dir, code)
+	case "python":
+		return r.analyzePython(ctx, dir, code)
+	case "javascript":
+		return r.analyzeJavaScript(ctx, dir, code)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedLanguage, language)
+	}
+}
+
+// goPositionPattern matches "main.go:3:14: message" lines of gofmt and go
+// vet. go vet prefixes type-checking errors with "vet: ".
+var goPositionPattern = regexp.MustCompile(`^(vet: )?(?:\./)?main\.go:(\d+):(\d+): (.*)$`)
+
+func (r *Runner) analyzeGo(ctx context.Context, dir, code string) ([]Diagnostic, error) {
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module solution\n\ngo 1.22\n"), 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(code), 0o644); err != nil {
+		return nil, err
+	}
+
+	limits := Limits{Timeout: analysisTimeout, MemoryMB: analysisMemoryMB}
+	gofmt, err := r.exec(ctx, dir, limits, maxFileBlocks, compileProcesses, goEnv(dir), "", "gofmt", "-l", "-e", "main.go")
+	if err != nil {
+		return nil, err
+	}
+
+	var diagnostics []Diagnostic
+	for _, line := range strings.Split(gofmt.Stderr, "\n") {
+		if m := goPositionPattern.FindStringSubmatch(line); m != nil {
+			diagnostics = append(diagnostics, diagnostic("gofmt", SeverityError, m[2], m[3], m[4]))
+		}
+	}
+	if len(diagnostics) > 0 {
+		// go vet cannot say more about code that does not parse
+		return diagnostics, nil
+	}
+	if strings.TrimSpace(gofmt.Stdout) != "" {
+		diagnostics = append(diagnostics, Diagnostic{Tool: "gofmt", Severity: SeverityWarning, Message: "code is not gofmt-formatted"})
+	}
+
+	limits.Timeout = vetTimeout
+	vet, err := r.exec(ctx, dir, limits, compileFileBlocks, compileProcesses, goEnv(dir), "", "go", "vet", ".")
+	if err != nil {
+		return nil, err
+	}
+	if vet.TimedOut {
+		return append(diagnostics, Diagnostic{Tool: "go vet", Severity: SeverityWarning, Message: "go vet timed out"}), nil
+	}
+	for _, line := range strings.Split(vet.Stderr, "\n") {
+		m := goPositionPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		severity := SeverityWarning
+		if m[1] != "" {
+			severity = SeverityError
+		}
+		diagnostics = append(diagnostics, diagnostic("go vet", severity, m[2], m[3], m[4]))
+	}
+
+	return diagnostics, nil
+}
+
+// pythonParseScript parses the file named by its first argument and prints
+// "line:column: message" for a syntax error.
+const pythonParseScript = `import ast, sys
+try:
+    ast.parse(open(sys.argv[1]).read(), sys.argv[1])
+except SyntaxError as e:
+    print(f"{e.lineno or 0}:{e.offset or 0}: {e.msg}")
+`
+
+var pythonPositionPattern = regexp.MustCompile(`^(\d+):(\d+): (.*)$`)
+
+func (r *Runner) analyzePython(ctx context.Context, dir, code string) ([]Diagnostic, error) {
+	if err := os.WriteFile(filepath.Join(dir, "main.py"), []byte(code), 0o644); err != nil {
+		return nil, err
+	}
+
+	limits := Limits{Timeout: analysisTimeout, MemoryMB: r.limits.MemoryMB}
+	result, err := r.exec(ctx, dir, limits, maxFileBlocks, maxProcesses, []string{"HOME=" + dir}, "", "python3", "-I", "-c", pythonParseScript, "main.py")
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode != 0 {
+		return nil, fmt.Errorf("python parser check failed: %s", strings.TrimSpace(result.Stderr))
+	}
+
+	var diagnostics []Diagnostic
+	if m := pythonPositionPattern.FindStringSubmatch(strings.TrimSpace(result.Stdout)); m != nil {
+		diagnostics = append(diagnostics, diagnostic("python parser", SeverityError, m[1], m[2], m[3]))
+	}
+	return diagnostics, nil
+}
+
+var (
+	// node --check reports the file and line of a syntax error on its
+	// first line and the error itself further down
+	nodeLinePattern  = regexp.MustCompile(`main\.js:(\d+)`)
+	nodeErrorPattern = regexp.MustCompile(`(?m)^\w*Error: (.*)$`)
+)
+
+func (r *Runner) analyzeJavaScript(ctx context.Context, dir, code string) ([]Diagnostic, error) {
+	if err := os.WriteFile(filepath.Join(dir, "main.js"), []byte(code), 0o644); err != nil {
+		return nil, err
+	}
+
+	limits := Limits{Timeout: analysisTimeout, MemoryMB: analysisMemoryMB}
+	result, err := r.exec(ctx, dir, limits, maxFileBlocks, maxProcesses, []string{"HOME=" + dir}, "", "node", "--check", "main.js")
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitCode == 0 {
+		return nil, nil
+	}
+
+	m := nodeErrorPattern.FindStringSubmatch(result.Stderr)
+	if m == nil {
+		return nil, fmt.Errorf("node syntax check failed: %s", strings.TrimSpace(result.Stderr))
+	}
+	line := "0"
+	if l := nodeLinePattern.FindStringSubmatch(result.Stderr); l != nil {
+		line = l[1]
+	}
+	return []Diagnostic{diagnostic("node --check", SeverityError, line, "0", m[1])}, nil
+}
+
+// diagnostic builds a Diagnostic from matched position strings.
+func diagnostic(tool, severity, line, column, message string) Diagnostic {
+	l, _ := strconv.Atoi(line)
+	c, _ := strconv.Atoi(column)
+	return Diagnostic{Tool: tool, Severity: severity, Line: l, Column: c, Message: strings.TrimSpace(message)}
+}
@@ -0,0 +1,261 @@
+//go:build linux
+
+package sandbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+// initArg is the argv[0] under which the server re-executes itself to set
+// up the sandbox from inside its namespaces before running the command.
+const initArg = "sandbox-init"
+
+// sandboxID is the user and group ID commands run as inside the sandbox.
+const sandboxID = 65534
+
+// Host user IDs for runs when the server runs as root. Every run gets its
+// own, so runs cannot signal each other and the process limit counts each
+// run's processes alone.
+const (
+	runUIDBase  = 200000
+	runUIDCount = 1 << 16
+)
+
+var nextRunUID atomic.Uint32
+
+// Constants of the Linux API the syscall package does not export.
+const (
+	capSysAdmin          = 21
+	rlimitNproc          = 6
+	prSetNoNewPrivs      = 38
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+)
+
+func init() {
+	if len(os.Args) > 0 && os.Args[0] == initArg {
+		runInit(os.Args[1:])
+	}
+}
+
+// isolate makes cmd run in new user, mount, PID, network, IPC and UTS
+// namespaces and in its own process group, so a timeout kills everything
+// it started. Inside, the root file system is read-only except for dir,
+// /proc only shows the sandbox's processes, the only network interface is
+// an unconfigured loopback and at most processes processes can run. The
+// command runs as an unprivileged user: its own host user ID when the
+// server runs as root, or else the server's.
+//
+// The returned function must be called once cmd has exited; it reports
+// whether setting up the sandbox failed.
+func isolate(cmd *exec.Cmd, dir string, processes int) (func() error, error) {
+	hostUID, hostGID := os.Getuid(), os.Getgid()
+	root := hostUID == 0
+	if root {
+		hostUID = runUIDBase + int(nextRunUID.Add(1)%runUIDCount)
+		hostGID = hostUID
+		if err := chownAll(dir, hostUID, hostGID); err != nil {
+			return nil, fmt.Errorf("failed to hand sandbox directory to run user: %w", err)
+		}
+	}
+
+	status, statusWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.Args = append([]string{initArg, strconv.Itoa(processes), dir, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/proc/self/exe"
+	cmd.ExtraFiles = []*os.File{statusWriter}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: sandboxID, HostID: hostUID, Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: sandboxID, HostID: hostGID, Size: 1}},
+		// Only root may drop the supplementary groups; other users'
+		// groups grant nothing extra
+		GidMappingsEnableSetgroups: root,
+		Credential:                 &syscall.Credential{Uid: sandboxID, Gid: sandboxID, Groups: []uint32{}, NoSetGroups: !root},
+		// Lets the init step mount; it drops the capability before
+		// running the command
+		AmbientCaps: []uintptr{capSysAdmin},
+		Setpgid:     true,
+		Pdeathsig:   syscall.SIGKILL,
+	}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	return func() error {
+		statusWriter.Close()
+		defer status.Close()
+		msg, err := io.ReadAll(status)
+		if err != nil {
+			return err
+		}
+		if len(msg) > 0 {
+			return fmt.Errorf("%w: %s", ErrUnavailable, msg)
+		}
+		return nil
+	}, nil
+}
+
+// chownAll gives dir and everything in it to uid and gid.
+func chownAll(dir string, uid, gid int) error {
+	return filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, uid, gid)
+	})
+}
+
+// runInit is the init step, running inside the new namespaces with args
+// processes, dir and the command. It sets up the sandbox and replaces
+// itself with the command. Setup errors are written to file descriptor 3,
+// which the command does not inherit.
+func runInit(args []string) {
+	status := os.NewFile(3, "status")
+	fail := func(err error) {
+		fmt.Fprint(status, err)
+		os.Exit(126)
+	}
+	if len(args) < 3 {
+		fail(errors.New("sandbox init: missing arguments"))
+	}
+	processes, err := strconv.Atoi(args[0])
+	if err != nil {
+		fail(fmt.Errorf("sandbox init: invalid process limit: %w", err))
+	}
+	dir, argv := args[1], args[2:]
+
+	if err := setUpSandbox(dir, processes); err != nil {
+		fail(fmt.Errorf("sandbox init: %w", err))
+	}
+
+	syscall.CloseOnExec(3)
+	fail(syscall.Exec(argv[0], argv, os.Environ()))
+}
+
+// setUpSandbox makes every mount but dir read-only, replaces /proc with one
+// for the new PID namespace, limits the number of processes and drops the
+// capabilities the init step was given.
+func setUpSandbox(dir string, processes int) error {
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	// A bind mount of dir onto itself stays writable below the read-only
+	// mount that contains it
+	if err := syscall.Mount(dir, dir, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("failed to mount %s: %w", dir, err)
+	}
+
+	mounts, err := mountPoints()
+	if err != nil {
+		return err
+	}
+	for _, m := range mounts {
+		if m == dir || strings.HasPrefix(m, dir+"/") || m == "/proc" || strings.HasPrefix(m, "/proc/") {
+			continue
+		}
+		if err := remountReadOnly(m); err != nil {
+			return err
+		}
+	}
+
+	// Without a fresh procfs, which the host may forbid, hide the host's
+	// processes behind an empty one
+	const procFlags = syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC
+	if err := syscall.Mount("proc", "/proc", "proc", procFlags, ""); err != nil {
+		if err := syscall.Mount("tmpfs", "/proc", "tmpfs", procFlags|syscall.MS_RDONLY, "size=0"); err != nil {
+			return fmt.Errorf("failed to mount /proc: %w", err)
+		}
+	}
+
+	if processes > 0 {
+		limit := &syscall.Rlimit{Cur: uint64(processes), Max: uint64(processes)}
+		if err := syscall.Setrlimit(rlimitNproc, limit); err != nil {
+			return fmt.Errorf("failed to limit processes: %w", err)
+		}
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %w", errno)
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0); errno != 0 {
+		return fmt.Errorf("failed to drop capabilities: %w", errno)
+	}
+	return syscall.Chdir(dir)
+}
+
+// mountPoints lists the mount points of the process's mount namespace,
+// parents before their children.
+func mountPoints() ([]string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mounts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, unescapeMountPoint(fields[4]))
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountPoint decodes the octal escapes mountinfo uses for spaces,
+// tabs, newlines and backslashes.
+func unescapeMountPoint(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// remountReadOnly makes the mount at path read-only, keeping the flags a
+// user namespace may not clear.
+func remountReadOnly(path string) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		// Mount points hidden under another mount cannot be reached
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat mount %s: %w", path, err)
+	}
+	if st.Flags&syscall.MS_RDONLY != 0 {
+		return nil
+	}
+
+	const kept = syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC |
+		syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME
+	flags := uintptr(st.Flags)&kept | syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY
+	if err := syscall.Mount("", path, "", flags, ""); err != nil {
+		return fmt.Errorf("failed to make mount %s read-only: %w", path, err)
+	}
+	return nil
+}
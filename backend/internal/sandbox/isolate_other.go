@@ -0,0 +1,14 @@
+//go:build !linux
+
+package sandbox
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// isolate refuses to run code on hosts without Linux namespaces.
+func isolate(cmd *exec.Cmd, dir string, processes int) (func() error, error) {
+	return nil, fmt.Errorf("%w: isolation is not supported on %s", ErrUnavailable, runtime.GOOS)
+}
@@ -0,0 +1,175 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxRequestBytes caps the body of a request to the runner service: the
+// code and the inputs of its test cases.
+const maxRequestBytes = 1 << 20
+
+// clientTimeout bounds a whole request to the runner service, which may
+// have to wait for a free build slot, compile and then run every input.
+const clientTimeout = 5 * time.Minute
+
+type executeRequest struct {
+	Language string   `json:"language"`
+	Code     string   `json:"code"`
+	Inputs   []string `json:"inputs"`
+}
+
+type analyzeRequest struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+// errorReply carries an error across the service. Kind names the sentinel
+// or type the client turns it back into.
+type errorReply struct {
+	Kind  string `json:"kind"`
+	Error string `json:"error"`
+}
+
+// Error kinds of errorReply.
+const (
+	kindCompile     = "compile"
+	kindUnsupported = "unsupported_language"
+	kindBusy        = "busy"
+	kindUnavailable = "unavailable"
+	kindOther       = "error"
+)
+
+// NewServer serves s to Clients: POST /execute runs code against inputs
+// and POST /analyze checks it. It is meant for a network only the backend
+// can reach, and does no authentication.
+func NewServer(s Sandbox) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /execute", func(w http.ResponseWriter, r *http.Request) {
+		var req executeRequest
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+		results, err := s.Execute(r.Context(), req.Language, req.Code, req.Inputs)
+		reply(w, results, err)
+	})
+	mux.HandleFunc("POST /analyze", func(w http.ResponseWriter, r *http.Request) {
+		var req analyzeRequest
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+		diagnostics, err := s.Analyze(r.Context(), req.Language, req.Code)
+		reply(w, diagnostics, err)
+	})
+	return mux
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorReply{Kind: kindOther, Error: "invalid request: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// reply writes v, or err with the status its kind maps to.
+func reply(w http.ResponseWriter, v interface{}, err error) {
+	var compileErr *CompileError
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, v)
+	case errors.As(err, &compileErr):
+		writeJSON(w, http.StatusUnprocessableEntity, errorReply{Kind: kindCompile, Error: compileErr.Output})
+	case errors.Is(err, ErrUnsupportedLanguage):
+		writeJSON(w, http.StatusBadRequest, errorReply{Kind: kindUnsupported, Error: err.Error()})
+	case errors.Is(err, ErrBusy):
+		writeJSON(w, http.StatusTooManyRequests, errorReply{Kind: kindBusy, Error: err.Error()})
+	case errors.Is(err, ErrUnavailable):
+		writeJSON(w, http.StatusServiceUnavailable, errorReply{Kind: kindUnavailable, Error: err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, errorReply{Kind: kindOther, Error: err.Error()})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Client is a Sandbox that runs code in a runner service, so the process
+// serving the API needs none of the privileges isolation takes.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient returns a Client of the runner service at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{baseURL: strings.TrimRight(baseURL, "/"), http: &http.Client{Timeout: clientTimeout}}
+}
+
+func (c *Client) Execute(ctx context.Context, language, code string, inputs []string) ([]Result, error) {
+	var results []Result
+	err := c.call(ctx, "/execute", executeRequest{Language: language, Code: code, Inputs: inputs}, &results)
+	return results, err
+}
+
+func (c *Client) Analyze(ctx context.Context, language, code string) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+	err := c.call(ctx, "/analyze", analyzeRequest{Language: language, Code: code}, &diagnostics)
+	return diagnostics, err
+}
+
+// call posts req to path and decodes the reply into out. A runner that
+// cannot be reached is reported as ErrUnavailable.
+func (c *Client) call(ctx context.Context, path string, req, out interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+
+	var e errorReply
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxRequestBytes))
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("%w: runner replied %s", ErrUnavailable, resp.Status)
+	}
+	switch e.Kind {
+	case kindCompile:
+		return &CompileError{Output: e.Error}
+	case kindUnsupported:
+		return fmt.Errorf("%w: %s", ErrUnsupportedLanguage, e.Error)
+	case kindBusy:
+		return ErrBusy
+	case kindUnavailable:
+		return fmt.Errorf("%w: %s", ErrUnavailable, e.Error)
+	default:
+		return fmt.Errorf("runner: %s", e.Error)
+	}
+}
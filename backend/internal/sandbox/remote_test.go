@@ -0,0 +1,92 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stubSandbox echoes every input back as its output, or fails with err.
+type stubSandbox struct {
+	err error
+}
+
+func (s stubSandbox) Execute(ctx context.Context, language, code string, inputs []string) ([]Result, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var results []Result
+	for _, input := range inputs {
+		results = append(results, Result{Stdout: input, ExitCode: 0, Duration: time.Millisecond})
+	}
+	return results, nil
+}
+
+func (s stubSandbox) Analyze(ctx context.Context, language, code string) ([]Diagnostic, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []Diagnostic{{Tool: "gofmt", Severity: SeverityWarning, Line: 3, Message: code}}, nil
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error // sentinel the client's error must match, nil for success
+	}{
+		{name: "success"},
+		{name: "unsupported language", err: fmt.Errorf("%w: %q", ErrUnsupportedLanguage, "rust"), wantErr: ErrUnsupportedLanguage},
+		{name: "busy", err: ErrBusy, wantErr: ErrBusy},
+		{name: "unavailable", err: fmt.Errorf("%w: operation not permitted", ErrUnavailable), wantErr: ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(NewServer(stubSandbox{err: tt.err}))
+			defer server.Close()
+			client := NewClient(server.URL + "/")
+
+			results, err := client.Execute(context.Background(), "python", "print(input())", []string{"1", "2"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Execute: err = %v, want one matching %v", err, tt.wantErr)
+				}
+			} else if err != nil || len(results) != 2 || results[1].Stdout != "2" || results[1].Duration != time.Millisecond {
+				t.Fatalf("Execute() = %+v, %v", results, err)
+			}
+
+			diagnostics, err := client.Analyze(context.Background(), "go", "x := 1")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Analyze: err = %v, want one matching %v", err, tt.wantErr)
+				}
+			} else if err != nil || len(diagnostics) != 1 || diagnostics[0].Line != 3 || diagnostics[0].Message != "x := 1" {
+				t.Fatalf("Analyze() = %+v, %v", diagnostics, err)
+			}
+		})
+	}
+}
+
+func TestClientCompileError(t *testing.T) {
+	server := httptest.NewServer(NewServer(stubSandbox{err: &CompileError{Output: "main.go:1:1: expected 'package'"}}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Execute(context.Background(), "go", "x", []string{""})
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) || compileErr.Output != "main.go:1:1: expected 'package'" {
+		t.Fatalf("err = %v, want the runner's compile error", err)
+	}
+}
+
+func TestClientUnreachable(t *testing.T) {
+	server := httptest.NewServer(NewServer(stubSandbox{}))
+	server.Close()
+
+	if _, err := NewClient(server.URL).Execute(context.Background(), "python", "", nil); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want one matching ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,341 @@
+// Package sandbox runs untrusted candidate code in a local subprocess with
+// CPU, memory, file size, process count and wall-clock limits, as an
+// unprivileged user on a read-only root file system and without network
+// access, and checks it with the language's formatters, linters and
+// parsers.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Languages lists the languages code can be run in.
+var Languages = []string{"go", "python"}
+
+var (
+	// ErrUnsupportedLanguage is returned for languages not in Languages.
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+
+	// ErrUnavailable is returned when the host cannot isolate the code, or
+	// the language toolchain is not installed. Callers should skip
+	// execution rather than fail the answer.
+	ErrUnavailable = errors.New("sandbox unavailable")
+
+	// ErrBusy is returned when too many builds and analyses are already
+	// running for another to start in time.
+	ErrBusy = errors.New("sandbox busy")
+)
+
+// Compiling needs far more memory, time, disk and processes than running
+// a solution.
+const (
+	compileTimeout    = 60 * time.Second
+	compileMemoryMB   = 2048
+	compileFileBlocks = 1 << 20
+	compileProcesses  = 512
+)
+
+// goReserveMB is the address space the Go runtime reserves at startup on
+// top of the heap. Go programs get it added to their memory limit, and
+// GOMEMLIMIT keeps their heap within the configured limit.
+const goReserveMB = 768
+
+// maxOutput caps how much of a program's stdout and stderr is kept.
+const maxOutput = 64 * 1024
+
+// maxFileBlocks caps the size of any file the program writes, in the
+// shell's 512-byte blocks.
+const maxFileBlocks = 2048
+
+// maxProcesses caps the processes and threads a program can run at once.
+const maxProcesses = 64
+
+// Limits bounds every run of candidate code.
+type Limits struct {
+	Timeout  time.Duration // wall-clock limit per run; also bounds CPU time
+	MemoryMB int           // address space limit per process
+}
+
+// maxConcurrentTools caps the builds and analyses running at once. Each
+// can take seconds of CPU and a gigabyte or more of memory.
+const maxConcurrentTools = 2
+
+// Sandbox runs and checks candidate code: a Runner in this process, or a
+// Client of a runner service in another container.
+type Sandbox interface {
+	Execute(ctx context.Context, language, code string, inputs []string) ([]Result, error)
+	Analyze(ctx context.Context, language, code string) ([]Diagnostic, error)
+}
+
+// Runner prepares and runs candidate programs.
+type Runner struct {
+	limits Limits
+	tools  chan struct{} // semaphore of maxConcurrentTools
+}
+
+// New creates a Runner enforcing limits.
+func New(limits Limits) *Runner {
+	return &Runner{limits: limits, tools: make(chan struct{}, maxConcurrentTools)}
+}
+
+// acquireTool waits up to wait for a free build or analysis slot. The
+// returned function releases it.
+func (r *Runner) acquireTool(ctx context.Context, wait time.Duration) (func(), error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case r.tools <- struct{}{}:
+		return func() { <-r.tools }, nil
+	case <-timer.C:
+		return nil, ErrBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// CompileError is returned by Prepare when the code does not compile.
+type CompileError struct {
+	Output string
+}
+
+func (e *CompileError) Error() string {
+	return "compile error: " + e.Output
+}
+
+// Result is the outcome of one run of a program.
+type Result struct {
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	ExitCode int           `json:"exit_code"`
+	TimedOut bool          `json:"timed_out"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Program is candidate code ready to run. Close removes its files.
+type Program struct {
+	runner *Runner
+	dir    string
+	argv   []string
+	env    []string
+	limits Limits
+}
+
+// Prepare writes code to a scratch directory and, for compiled languages,
+// builds it.
+func (r *Runner) Prepare(ctx context.Context, language, code string) (*Program, error) {
+	dir, err := os.MkdirTemp("", "sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	p := &Program{runner: r, dir: dir, env: []string{"HOME=" + dir}, limits: r.limits}
+
+	switch language {
+	case "go":
+		err = p.prepareGo(ctx, code)
+	case "python":
+		err = p.preparePython(code)
+	default:
+		err = fmt.Errorf("%w: %q", ErrUnsupportedLanguage, language)
+	}
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Program) prepareGo(ctx context.Context, code string) error {
+	if err := os.WriteFile(filepath.Join(p.dir, "go.mod"), []byte("module solution\n\ngo 1.22\n"), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(p.dir, "main.go"), []byte(code), 0o644); err != nil {
+		return err
+	}
+
+	release, err := p.runner.acquireTool(ctx, compileTimeout)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	limits := Limits{Timeout: compileTimeout, MemoryMB: compileMemoryMB}
+	result, err := p.runner.exec(ctx, p.dir, limits, compileFileBlocks, compileProcesses, goEnv(p.dir), "", "go", "build", "-o", "solution", ".")
+	if err != nil {
+		return err
+	}
+	if result.TimedOut {
+		return &CompileError{Output: "compilation timed out"}
+	}
+	if result.ExitCode != 0 {
+		return &CompileError{Output: strings.TrimSpace(result.Stderr + result.Stdout)}
+	}
+
+	p.argv = []string{"./solution"}
+	p.env = append(p.env, fmt.Sprintf("GOMEMLIMIT=%dMiB", p.limits.MemoryMB))
+	p.limits.MemoryMB += goReserveMB
+	return nil
+}
+
+// goEnv is the environment of the go tool working on a program in dir. It
+// never downloads modules or toolchains. The build cache is the program's
+// own, since a shared one could be poisoned by an earlier candidate.
+func goEnv(dir string) []string {
+	return []string{
+		"HOME=" + dir,
+		"GOPATH=" + filepath.Join(dir, "gopath"),
+		"GOCACHE=" + filepath.Join(dir, "gocache"),
+		"GOPROXY=off",
+		"GOTOOLCHAIN=local",
+		"GOFLAGS=-mod=mod",
+		"CGO_ENABLED=0",
+	}
+}
+
+func (p *Program) preparePython(code string) error {
+	if err := os.WriteFile(filepath.Join(p.dir, "main.py"), []byte(code), 0o644); err != nil {
+		return err
+	}
+	// -I ignores PYTHON* variables and the user site directory
+	p.argv = []string{"python3", "-I", "main.py"}
+	return nil
+}
+
+// Run runs the program once with stdin as its input.
+func (p *Program) Run(ctx context.Context, stdin string) (*Result, error) {
+	return p.runner.exec(ctx, p.dir, p.limits, maxFileBlocks, maxProcesses, p.env, stdin, p.argv...)
+}
+
+// Close removes the program's files.
+func (p *Program) Close() error {
+	return os.RemoveAll(p.dir)
+}
+
+// Execute prepares code and runs it once with each of inputs as its
+// input. Code that does not compile yields a *CompileError.
+func (r *Runner) Execute(ctx context.Context, language, code string, inputs []string) ([]Result, error) {
+	program, err := r.Prepare(ctx, language, code)
+	if err != nil {
+		return nil, err
+	}
+	defer program.Close()
+
+	results := make([]Result, 0, len(inputs))
+	for _, input := range inputs {
+		result, err := program.Run(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// exec runs argv in dir under limits and isolated by isolate, with files
+// capped at fileBlocks and at most processes processes. dir is the only
+// place it can write to. A non-zero exit or a timeout is reported in the
+// Result, not as an error.
+func (r *Runner) exec(ctx context.Context, dir string, limits Limits, fileBlocks, processes int, env []string, stdin string, argv ...string) (*Result, error) {
+	// The root file system is read-only, so temporary files go to dir too.
+	// dir itself cannot be the temporary directory: the go tool ignores a
+	// go.mod there
+	tmp := filepath.Join(dir, ".tmp")
+	if err := os.MkdirAll(tmp, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	// The limits are applied by the shell so they are in place before the
+	// program starts
+	cpuSeconds := int(limits.Timeout.Seconds()) + 1
+	script := fmt.Sprintf(`ulimit -t %d && ulimit -v %d && ulimit -f %d && exec "$@"`, cpuSeconds, limits.MemoryMB*1024, fileBlocks)
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", script, "sandbox"}, argv...)...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH"), "TMPDIR=" + tmp}, env...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	stdout := &limitedBuffer{limit: maxOutput}
+	stderr := &limitedBuffer{limit: maxOutput}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.WaitDelay = time.Second
+	setupErr, err := isolate(cmd, dir, processes)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		setupErr()
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	err = cmd.Wait()
+	if err := setupErr(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: cmd.ProcessState.ExitCode(),
+		TimedOut: ctx.Err() == context.DeadlineExceeded,
+		Duration: time.Since(start),
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && !result.TimedOut {
+		return nil, err
+	}
+	// The shell reports a missing interpreter or compiler as exit code 127
+	if result.ExitCode == 127 && strings.Contains(result.Stderr, "not found") {
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, strings.TrimSpace(result.Stderr))
+	}
+
+	return result, nil
+}
+
+// OutputMatches reports whether a program's output equals the expected
+// output, ignoring trailing whitespace on each line and trailing blank
+// lines.
+func OutputMatches(output, expected string) bool {
+	return normalizeOutput(output) == normalizeOutput(expected)
+}
+
+func normalizeOutput(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest, so a runaway program cannot exhaust the server's memory.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,133 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutputMatches(t *testing.T) {
+	tests := []struct {
+		output, expected string
+		want             bool
+	}{
+		{"3\n", "3", true},
+		{"1 2 \r\n3\n\n", "1 2\n3", true},
+		{"1\n\n2", "1\n2", false},
+		{" 3", "3", false},
+		{"", "", true},
+		{"3", "4", false},
+	}
+	for _, tt := range tests {
+		if got := OutputMatches(tt.output, tt.expected); got != tt.want {
+			t.Errorf("OutputMatches(%q, %q) = %v, want %v", tt.output, tt.expected, got, tt.want)
+		}
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{limit: 5}
+	for _, s := range []string{"abc", "defg", "hij"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if got := b.String(); got != "abcde" {
+		t.Errorf("kept %q, want %q", got, "abcde")
+	}
+}
+
+// isolationProbe reports what a candidate program can reach, one
+// "check: result" line per check.
+const isolationProbe = `import os, socket, subprocess
+
+def attempt(name, f):
+    try:
+        f()
+        print(name + ": allowed")
+    except Exception:
+        print(name + ": denied")
+
+def write(path):
+    with open(path, "w") as f:
+        f.write("x")
+
+print("uid: %d" % os.getuid())
+attempt("write work dir", lambda: write("out.txt"))
+attempt("write /tmp", lambda: write("/tmp/sandbox-escape"))
+attempt("write /etc", lambda: write("/etc/sandbox-escape"))
+attempt("network", lambda: socket.create_connection(("1.1.1.1", 53), timeout=1))
+print("processes: %d" % len([p for p in os.listdir("/proc") if p.isdigit()]))
+
+children = []
+def spawn():
+    for _ in range(200):
+        children.append(subprocess.Popen(["sleep", "5"]))
+attempt("200 processes", spawn)
+for c in children:
+    c.kill()
+`
+
+func TestIsolation(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	runner := New(Limits{Timeout: 10 * time.Second, MemoryMB: 256})
+	program, err := runner.Prepare(context.Background(), "python", isolationProbe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer program.Close()
+
+	result, err := program.Run(context.Background(), "")
+	if errors.Is(err, ErrUnavailable) {
+		t.Skipf("sandbox unavailable on this host: %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 0 {
+		t.Fatalf("probe exited with code %d: %s", result.ExitCode, result.Stderr)
+	}
+
+	for _, want := range []string{
+		"uid: 65534",
+		"write work dir: allowed",
+		"write /tmp: denied",
+		"write /etc: denied",
+		"network: denied",
+		"processes: 1",
+		"200 processes: denied",
+	} {
+		if !strings.Contains(result.Stdout, want+"\n") {
+			t.Errorf("probe output lacks %q:\n%s", want, result.Stdout)
+		}
+	}
+}
+
+func TestAcquireToolBusy(t *testing.T) {
+	runner := New(Limits{Timeout: time.Second, MemoryMB: 256})
+
+	var releases []func()
+	for i := 0; i < maxConcurrentTools; i++ {
+		release, err := runner.acquireTool(context.Background(), time.Millisecond)
+		if err != nil {
+			t.Fatalf("slot %d: %v", i+1, err)
+		}
+		releases = append(releases, release)
+	}
+	if _, err := runner.acquireTool(context.Background(), time.Millisecond); !errors.Is(err, ErrBusy) {
+		t.Fatalf("acquire with every slot taken: err = %v, want ErrBusy", err)
+	}
+
+	releases[0]()
+	release, err := runner.acquireTool(context.Background(), time.Millisecond)
+	if err != nil {
+		t.Fatalf("acquire after a release: %v", err)
+	}
+	release()
+}
@@ -10,24 +10,52 @@ function Home() {
     email: '',
     position: '',
     difficulty: 'medium',
+    adaptive: false,
+    mode: 'practice',
   });
+  const [resume, setResume] = useState(null);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState('');
 
   const handleChange = (e) => {
     setFormData({
       ...formData,
-      [e.target.name]: e.target.value,
+      [e.target.name]: e.target.type === 'checkbox' ? e.target.checked : e.target.value,
     });
   };
 
+  // Text and Markdown resumes are sent as they are, PDFs base64-encoded
+  const handleResumeChange = (e) => {
+    const file = e.target.files[0];
+    setResume(null);
+    if (!file) {
+      return;
+    }
+
+    const name = file.name.toLowerCase();
+    const format = name.endsWith('.pdf') ? 'pdf' : name.endsWith('.md') ? 'markdown' : 'text';
+    const reader = new FileReader();
+    reader.onload = () => {
+      const data = format === 'pdf' ? reader.result.split(',')[1] : reader.result;
+      setResume({ format, data });
+    };
+    reader.onerror = () => setError('Failed to read the resume file');
+    if (format === 'pdf') {
+      reader.readAsDataURL(file);
+    } else {
+      reader.readAsText(file);
+    }
+  };
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     setError('');
     setLoading(true);
 
     try {
-      const response = await interviewAPI.startInterview(formData);
+      const response = await interviewAPI.startInterview(
+        resume ? { ...formData, resume } : formData
+      );
       navigate(`/interview/${response.interview_id}`);
     } catch (err) {
       setError(err.response?.data?.error || 'Failed to start interview. Please try again.');
@@ -188,6 +216,52 @@ function Home() {
                 </select>
               </div>
 
+              <div className="form-group">
+                <label className="form-label" htmlFor="mode">
+                  Mode
+                </label>
+                <select
+                  id="mode"
+                  name="mode"
+                  className="form-select"
+                  value={formData.mode}
+                  onChange={handleChange}
+                >
+                  <option value="practice">Practice - hints and model answers</option>
+                  <option value="assessment">Assessment - no model answers</option>
+                </select>
+              </div>
+
+              <div className="form-group">
+                <label className="form-label" htmlFor="resume">
+                  Resume (optional)
+                </label>
+                <input
+                  type="file"
+                  id="resume"
+                  name="resume"
+                  className="form-input"
+                  accept=".txt,.md,.pdf"
+                  onChange={handleResumeChange}
+                />
+                <small className="form-hint">
+                  Questions will probe the skills and projects on your resume
+                </small>
+              </div>
+
+              <div className="form-group">
+                <label className="form-label" htmlFor="adaptive">
+                  <input
+                    type="checkbox"
+                    id="adaptive"
+                    name="adaptive"
+                    checked={formData.adaptive}
+                    onChange={handleChange}
+                  />{' '}
+                  Adaptive difficulty (adjusts to your answers)
+                </label>
+              </div>
+
               <div className="form-actions">
                 <button
                   type="submit"
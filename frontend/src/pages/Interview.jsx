@@ -10,8 +10,13 @@ function Interview() {
   const [submitting, setSubmitting] = useState(false);
   const [currentQuestion, setCurrentQuestion] = useState(null);
   const [answer, setAnswer] = useState('');
+  const [code, setCode] = useState('');
+  const [selected, setSelected] = useState([]);
+  const [language, setLanguage] = useState('python');
   const [feedback, setFeedback] = useState(null);
   const [questionNumber, setQuestionNumber] = useState(1);
+  const [hints, setHints] = useState([]);
+  const [hintsExhausted, setHintsExhausted] = useState(false);
   const [error, setError] = useState('');
 
   useEffect(() => {
@@ -44,6 +49,9 @@ function Interview() {
 
       if (unansweredQuestion) {
         setCurrentQuestion(unansweredQuestion);
+        const questionHints = (data.hints || []).filter(h => h.question_id === unansweredQuestion.id);
+        setHints(questionHints);
+        setHintsExhausted(questionHints.length >= 3);
         const questionIndex = data.questions.findIndex(q => q.id === unansweredQuestion.id);
         setQuestionNumber(questionIndex + 1);
       } else {
@@ -59,11 +67,33 @@ function Interview() {
     }
   };
 
+  const handleHint = async () => {
+    setError('');
+    try {
+      const response = await interviewAPI.getHint(currentQuestion.id);
+      setHints([...hints, response.hint]);
+      setHintsExhausted(response.hints_used >= response.max_hints);
+    } catch (err) {
+      if (err.response?.status === 409) {
+        setHintsExhausted(true);
+      }
+      setError(err.response?.data?.error || 'Failed to get hint');
+    }
+  };
+
+  const toggleOption = (index) => {
+    if (currentQuestion.question_type === 'single_choice') {
+      setSelected([index]);
+    } else {
+      setSelected(selected.includes(index) ? selected.filter((i) => i !== index) : [...selected, index]);
+    }
+  };
+
   const handleSubmit = async (e) => {
     e.preventDefault();
     
-    if (!answer.trim()) {
-      setError('Please provide an answer');
+    if (isChoice ? selected.length === 0 : !answer.trim()) {
+      setError(isChoice ? 'Please choose an answer' : 'Please provide an answer');
       return;
     }
 
@@ -71,14 +101,19 @@ function Interview() {
     setError('');
 
     try {
+      const codeLanguage = currentQuestion.question_type === 'sql' ? 'sql' : language;
       const response = await interviewAPI.submitAnswer({
         question_id: currentQuestion.id,
-        response_text: answer,
+        response_text: isChoice ? selected.map((i) => currentQuestion.options[i]).join('\n') : answer,
+        ...(code.trim() && { language: codeLanguage, code }),
+        ...(isChoice && { selected_options: selected }),
       });
 
       setFeedback({
         feedback: response.feedback,
         score: response.score,
+        status: response.status,
+        queryResult: response.query_result,
       });
 
       // Wait a moment to show feedback, then move to next question or results
@@ -89,7 +124,11 @@ function Interview() {
           setCurrentQuestion(response.next_question);
           setQuestionNumber(questionNumber + 1);
           setAnswer('');
+          setCode('');
+          setSelected([]);
           setFeedback(null);
+          setHints([]);
+          setHintsExhausted(false);
         }
       }, 3000);
     } catch (err) {
@@ -99,6 +138,8 @@ function Interview() {
     }
   };
 
+  const isChoice = ['single_choice', 'multi_choice'].includes(currentQuestion?.question_type);
+
   if (loading) {
     return (
       <div className="loading">
@@ -122,11 +163,24 @@ function Interview() {
           <div className="progress-info">
             <span className="question-counter">Question {questionNumber}</span>
             <span className="question-type">{currentQuestion?.question_type}</span>
+            {currentQuestion?.parent_id && (
+              <span className="question-type">follow-up</span>
+            )}
           </div>
         </div>
 
         <div className="question-card card">
           <h2 className="question-text">{currentQuestion?.question_text}</h2>
+          {currentQuestion?.dataset && (
+            <pre className="answer-text">{currentQuestion.dataset.schema}</pre>
+          )}
+          {hints.length > 0 && (
+            <ul className="hint-list">
+              {hints.map((h) => (
+                <li key={h.level}>Hint {h.level}: {h.hint_text}</li>
+              ))}
+            </ul>
+          )}
         </div>
 
         {feedback ? (
@@ -135,9 +189,18 @@ function Interview() {
               <div className="feedback-header">
                 <h3>Feedback</h3>
                 <div className="score-badge">
-                  Score: {feedback.score.toFixed(1)}/10
+                  {feedback.score != null
+                    ? `Score: ${feedback.score.toFixed(1)}/10`
+                    : feedback.status === 'pending' ? 'Pending evaluation' : 'Unscored'}
                 </div>
               </div>
+              {feedback.queryResult && (
+                <p className="feedback-text">
+                  Query result: {feedback.queryResult.error
+                    ? feedback.queryResult.error
+                    : `${feedback.queryResult.score.toFixed(1)}/10 for correctness`}
+                </p>
+              )}
               <p className="feedback-text">{feedback.feedback}</p>
               <p className="next-question-info">Moving to next question...</p>
             </div>
@@ -147,30 +210,111 @@ function Interview() {
             <form onSubmit={handleSubmit}>
               {error && <div className="error-message">{error}</div>}
               
-              <div className="form-group">
-                <label className="form-label" htmlFor="answer">
-                  Your Answer
-                </label>
-                <textarea
-                  id="answer"
-                  className="form-textarea"
-                  value={answer}
-                  onChange={(e) => setAnswer(e.target.value)}
-                  placeholder="Type your answer here..."
-                  rows="8"
-                  disabled={submitting}
-                  required
-                />
-              </div>
+              {isChoice ? (
+                <div className="form-group">
+                  <span className="form-label">
+                    {currentQuestion.question_type === 'single_choice' ? 'Choose one' : 'Choose all that apply'}
+                  </span>
+                  {currentQuestion.options.map((option, i) => (
+                    <label key={i} className="option">
+                      <input
+                        type={currentQuestion.question_type === 'single_choice' ? 'radio' : 'checkbox'}
+                        name="options"
+                        checked={selected.includes(i)}
+                        onChange={() => toggleOption(i)}
+                        disabled={submitting}
+                      />{' '}
+                      {option}
+                    </label>
+                  ))}
+                </div>
+              ) : (
+                <div className="form-group">
+                  <label className="form-label" htmlFor="answer">
+                    Your Answer
+                  </label>
+                  <textarea
+                    id="answer"
+                    className="form-textarea"
+                    value={answer}
+                    onChange={(e) => setAnswer(e.target.value)}
+                    placeholder={
+                      currentQuestion?.question_type === 'coding' || currentQuestion?.question_type === 'sql'
+                        ? 'Explain your approach here...'
+                        : currentQuestion?.question_type === 'system_design'
+                          ? 'Describe your design. You can draw it in a ```mermaid or ```plantuml block...'
+                          : 'Type your answer here...'
+                    }
+                    rows="8"
+                    disabled={submitting}
+                    required
+                  />
+                </div>
+              )}
+
+              {currentQuestion?.question_type === 'coding' && (
+                <div className="form-group">
+                  <label className="form-label" htmlFor="code">
+                    Your Code
+                  </label>
+                  <select
+                    id="language"
+                    className="form-select"
+                    value={language}
+                    onChange={(e) => setLanguage(e.target.value)}
+                    disabled={submitting}
+                  >
+                    <option value="python">Python</option>
+                    <option value="go">Go</option>
+                    <option value="javascript">JavaScript</option>
+                  </select>
+                  <textarea
+                    id="code"
+                    className="form-textarea"
+                    value={code}
+                    onChange={(e) => setCode(e.target.value)}
+                    placeholder="A complete program reading stdin and writing stdout. Go and Python programs are run against the tests."
+                    rows="12"
+                    disabled={submitting}
+                    spellCheck={false}
+                  />
+                </div>
+              )}
+
+              {currentQuestion?.question_type === 'sql' && (
+                <div className="form-group">
+                  <label className="form-label" htmlFor="code">
+                    Your Query
+                  </label>
+                  <textarea
+                    id="code"
+                    className="form-textarea"
+                    value={code}
+                    onChange={(e) => setCode(e.target.value)}
+                    placeholder="A single SQLite SELECT query. It is run against the tables above and its result is checked."
+                    rows="8"
+                    disabled={submitting}
+                    spellCheck={false}
+                  />
+                </div>
+              )}
 
               <div className="answer-actions">
                 <button
                   type="submit"
                   className="btn btn-primary"
-                  disabled={submitting || !answer.trim()}
+                  disabled={submitting || (isChoice ? selected.length === 0 : !answer.trim())}
                 >
                   {submitting ? 'Submitting...' : 'Submit Answer'}
                 </button>
+                <button
+                  type="button"
+                  className="btn"
+                  onClick={handleHint}
+                  disabled={submitting || hintsExhausted}
+                >
+                  Get Hint
+                </button>
               </div>
             </form>
           </div>
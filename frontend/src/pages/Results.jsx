@@ -9,6 +9,7 @@ function Results() {
   const [loading, setLoading] = useState(true);
   const [result, setResult] = useState(null);
   const [error, setError] = useState('');
+  const [modelAnswers, setModelAnswers] = useState({});
 
   useEffect(() => {
     loadResults();
@@ -25,6 +26,18 @@ function Results() {
     }
   };
 
+  const loadModelAnswer = async (questionId) => {
+    try {
+      const data = await interviewAPI.getModelAnswer(questionId);
+      setModelAnswers({ ...modelAnswers, [questionId]: data });
+    } catch (err) {
+      setModelAnswers({
+        ...modelAnswers,
+        [questionId]: { error: err.response?.data?.error || 'Failed to load model answer' },
+      });
+    }
+  };
+
   const getScoreColor = (score) => {
     if (score >= 8) return '#00ff41';
     if (score >= 6) return '#ffff00';
@@ -77,23 +90,59 @@ function Results() {
               </h2>
               <p className="interview-meta">
                 Position: {result.interview.position} | 
-                Difficulty: {result.interview.difficulty}
+                Difficulty: {result.interview.adaptive
+                  ? result.difficulty_trajectory.join(' → ')
+                  : result.interview.difficulty}
               </p>
             </div>
           </div>
         </div>
 
+        {result.interview.final_feedback && (
+          <div className="card">
+            <h2 className="section-title">Overall Feedback</h2>
+            <p className="feedback-text-result">{result.interview.final_feedback.summary}</p>
+            <p className="interview-meta">
+              Recommendation: {result.interview.final_feedback.recommendation.replace('_', ' ')}
+            </p>
+            {result.interview.final_feedback.strengths.length > 0 && (
+              <div className="rubric-section-result">
+                <h4 className="subsection-title">Strengths:</h4>
+                <ul className="rubric-list">
+                  {result.interview.final_feedback.strengths.map((s) => (
+                    <li key={s}>{s}</li>
+                  ))}
+                </ul>
+              </div>
+            )}
+            {result.interview.final_feedback.improvements.length > 0 && (
+              <div className="rubric-section-result">
+                <h4 className="subsection-title">Areas for Improvement:</h4>
+                <ul className="rubric-list">
+                  {result.interview.final_feedback.improvements.map((s) => (
+                    <li key={s}>{s}</li>
+                  ))}
+                </ul>
+              </div>
+            )}
+          </div>
+        )}
+
         <div className="questions-results">
           <h2 className="section-title">Question-by-Question Breakdown</h2>
           
           {result.questions.map((question, index) => {
             const response = result.responses.find(r => r.question_id === question.id);
+            const hints = (result.hints || []).filter(h => h.question_id === question.id);
             
             return (
               <div key={question.id} className="question-result card">
                 <div className="question-result-header">
                   <span className="question-number">Question {index + 1}</span>
                   <span className="question-type-badge">{question.question_type}</span>
+                  {question.parent_id && (
+                    <span className="question-type-badge">follow-up</span>
+                  )}
                   {response?.score && (
                     <span 
                       className="question-score"
@@ -117,7 +166,161 @@ function Results() {
                       <div className="feedback-section-result">
                         <h4 className="subsection-title">Feedback:</h4>
                         <p className="feedback-text-result">{response.feedback}</p>
+                        {response.needs_review && (
+                          <p className="interview-meta">Flagged for human review: {response.review_reason}</p>
+                        )}
                       </div>
+
+                      {hints.length > 0 && (
+                        <div className="rubric-section-result">
+                          <h4 className="subsection-title">
+                            Hints Used{response.hint_penalty > 0 && ` (−${response.hint_penalty.toFixed(1)} points)`}:
+                          </h4>
+                          <ul className="rubric-list">
+                            {hints.map((h) => (
+                              <li key={h.level}>{h.level}. {h.hint_text}</li>
+                            ))}
+                          </ul>
+                        </div>
+                      )}
+
+                      {(response.key_points_covered?.length > 0 || response.key_points_missed?.length > 0) && (
+                        <div className="rubric-section-result">
+                          <h4 className="subsection-title">Key Points:</h4>
+                          <ul className="rubric-list">
+                            {response.key_points_covered.map((kp) => (
+                              <li key={`covered-${kp}`}>✓ {kp}</li>
+                            ))}
+                            {response.key_points_missed.map((kp) => (
+                              <li key={`missed-${kp}`}>✗ {kp}</li>
+                            ))}
+                          </ul>
+                        </div>
+                      )}
+
+                      {response.code && (
+                        <div className="rubric-section-result">
+                          <h4 className="subsection-title">Code ({response.language}):</h4>
+                          <pre className="answer-text">{response.code}</pre>
+                        </div>
+                      )}
+
+                      {response.diagnostics?.length > 0 && (
+                        <div className="rubric-section-result">
+                          <h4 className="subsection-title">Static Analysis:</h4>
+                          <ul className="rubric-list">
+                            {response.diagnostics.map((d) => (
+                              <li key={d.id}>
+                                <strong>{d.severity}</strong> {d.tool}{d.line ? ` (line ${d.line})` : ''}: {d.message}
+                              </li>
+                            ))}
+                          </ul>
+                        </div>
+                      )}
+
+                      {response.diagrams?.map((d, i) => (
+                        <div key={d.id} className="rubric-section-result">
+                          <h4 className="subsection-title">Diagram {i + 1} ({d.format}):</h4>
+                          {d.error ? (
+                            <p className="answer-text">Invalid diagram: {d.error}</p>
+                          ) : (
+                            <ul className="rubric-list">
+                              <li>
+                                <strong>Components:</strong> {d.components.map((c) => c.label).join(', ')}
+                              </li>
+                              {d.edges.map((e, j) => (
+                                <li key={j}>
+                                  {e.from} → {e.to}{e.label ? `: ${e.label}` : ''}
+                                </li>
+                              ))}
+                            </ul>
+                          )}
+                        </div>
+                      ))}
+
+                      {response.test_results?.length > 0 && (
+                        <div className="rubric-section-result">
+                          <h4 className="subsection-title">
+                            Tests: {response.test_results.filter((t) => t.passed).length}/{response.test_results.length} passed
+                          </h4>
+                          <ul className="rubric-list">
+                            {response.test_results.map((t, i) => (
+                              <li key={t.id}>
+                                {t.passed ? '✓' : '✗'} Test {i + 1} {t.passed ? 'passed' : 'failed'} ({t.duration_ms} ms)
+                              </li>
+                            ))}
+                          </ul>
+                        </div>
+                      )}
+
+                      {response.query_result && (
+                        <div className="rubric-section-result">
+                          <h4 className="subsection-title">
+                            Query Result: {response.query_result.score.toFixed(1)}/10 for correctness
+                          </h4>
+                          {response.query_result.error ? (
+                            <p className="answer-text">{response.query_result.error}</p>
+                          ) : (
+                            <>
+                              <p className="feedback-text-result">
+                                {response.query_result.reason ||
+                                  `Matched all ${response.query_result.expected_rows} expected rows.`}
+                              </p>
+                              <table>
+                                <thead>
+                                  <tr>
+                                    {response.query_result.columns.map((c, i) => (
+                                      <th key={i}>{c}</th>
+                                    ))}
+                                  </tr>
+                                </thead>
+                                <tbody>
+                                  {response.query_result.rows.map((row, i) => (
+                                    <tr key={i}>
+                                      {row.map((v, j) => (
+                                        <td key={j}>{v}</td>
+                                      ))}
+                                    </tr>
+                                  ))}
+                                </tbody>
+                              </table>
+                            </>
+                          )}
+                        </div>
+                      )}
+
+                      {result.interview.mode !== 'assessment' && response.status === 'scored' && (
+                        <div className="rubric-section-result">
+                          {modelAnswers[question.id] ? (
+                            modelAnswers[question.id].error ? (
+                              <p className="error-message">{modelAnswers[question.id].error}</p>
+                            ) : (
+                              <>
+                                <h4 className="subsection-title">Model Answer:</h4>
+                                <p className="answer-text">{modelAnswers[question.id].model_answer}</p>
+                                <p className="feedback-text-result">{modelAnswers[question.id].explanation}</p>
+                              </>
+                            )
+                          ) : (
+                            <button className="btn" onClick={() => loadModelAnswer(question.id)}>
+                              Show Model Answer
+                            </button>
+                          )}
+                        </div>
+                      )}
+
+                      {response.rubric?.length > 0 && (
+                        <div className="rubric-section-result">
+                          <h4 className="subsection-title">Rubric:</h4>
+                          <ul className="rubric-list">
+                            {response.rubric.map((r) => (
+                              <li key={r.dimension}>
+                                <strong>{r.dimension.replace('_', ' ')}: {r.score.toFixed(1)}/10</strong> — {r.justification}
+                              </li>
+                            ))}
+                          </ul>
+                        </div>
+                      )}
                     </>
                   )}
                 </div>
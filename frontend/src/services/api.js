@@ -22,6 +22,18 @@ export const interviewAPI = {
     return response.data;
   },
 
+  // Get the next hint for a question
+  getHint: async (questionId) => {
+    const response = await api.post(`/interview/question/${questionId}/hint`);
+    return response.data;
+  },
+
+  // Get the model answer for an evaluated question (practice mode only)
+  getModelAnswer: async (questionId) => {
+    const response = await api.get(`/interview/question/${questionId}/model-answer`);
+    return response.data;
+  },
+
   // Get interview details
   getInterview: async (id) => {
     const response = await api.get(`/interview/${id}`);